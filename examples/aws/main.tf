@@ -1,5 +1,5 @@
 provider "pks" {
-  skip_ssl_validation = true
+  ca_cert_file = var.pks_ca_cert_file
 }
 
 provider "aws" {}
@@ -59,6 +59,10 @@ variable "cluster_name" {
 }
 
 
+variable "pks_ca_cert_file" {
+  type = "string"
+}
+
 variable "k8s_api_dns_suffix" {
   type = "string"
 }
@@ -2,7 +2,7 @@
 provider "pks" {
   hostname = "${var.pks_api_dns_name}"
   token = "${var.token}"
-  skip_ssl_validation = true
+  ca_cert_file = "${var.pks_ca_cert_file}"
 }
 
 resource "pks_cluster" "example" {
@@ -21,6 +21,10 @@ variable "pks_api_dns_name" {
   type = "string"
 }
 
+variable "pks_ca_cert_file" {
+  type = "string"
+}
+
 variable "token" {
   type = "string"
 }
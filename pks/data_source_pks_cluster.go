@@ -0,0 +1,179 @@
+package pks
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"strings"
+)
+
+func dataSourcePksCluster() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePksClusterRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the cluster to look up",
+			},
+
+			"external_hostname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Hostname assigned to the Kubernetes API",
+			},
+
+			"kubernetes_master_port": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Port the Kubernetes API listens on",
+			},
+
+			"plan": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Plan the cluster was created with",
+			},
+
+			"num_nodes": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of worker nodes",
+			},
+
+			"compute_profile_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"kubernetes_profile_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network_profile_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"node_pool": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"instances": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+
+						"node_labels": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"node_taints": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"master_ips": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "IPs assigned to the master VMs",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"uuid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Unique ID for the cluster, use this to lookup the cluster with BOSH",
+			},
+
+			"k8s_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"pks_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"last_action": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Last action performed on the cluster through PKS, one of CREATE, UPDATE, UPGRADE, DELETE",
+			},
+
+			"last_action_state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "One of: in progress, succeeded",
+			},
+
+			"last_action_description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourcePksClusterRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Get("name").(string)
+
+	cr, exists, err := pksClient.api.GetCluster(pksClient.stopCtx, name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("Cluster %q not found in PKS", name)
+	}
+
+	if strings.EqualFold(cr.LastActionState, "failed") {
+		return fmt.Errorf("Last action %q on cluster %q failed with error: %q", cr.LastAction, name, cr.LastActionDescription)
+	}
+
+	d.SetId(cr.Name)
+	d.Set("name", cr.Name)
+	d.Set("external_hostname", cr.Parameters.KubernetesMasterHost)
+	d.Set("kubernetes_master_port", cr.Parameters.KubernetesMasterPort)
+	d.Set("plan", cr.PlanName)
+	d.Set("num_nodes", cr.Parameters.KubernetesWorkerInstances)
+	d.Set("compute_profile_name", cr.Parameters.ComputeProfileName)
+	d.Set("kubernetes_profile_name", cr.Parameters.KubernetesProfileName)
+	d.Set("network_profile_name", cr.Parameters.NsxtNetworkProfile)
+	if err := d.Set("tags", flattenClusterTags(cr.Parameters.Tags)); err != nil {
+		return err
+	}
+	if err := d.Set("node_pool", flattenClusterNodePools(cr.Parameters.NodePools)); err != nil {
+		return err
+	}
+	d.Set("master_ips", cr.KubernetesMasterIps)
+	d.Set("uuid", cr.Uuid)
+	d.Set("k8s_version", cr.K8sVersion)
+	d.Set("pks_version", cr.PksVersion)
+	d.Set("last_action", cr.LastAction)
+	d.Set("last_action_state", cr.LastActionState)
+	d.Set("last_action_description", cr.LastActionDescription)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package pks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourcePksClusterCredentials() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePksClusterCredentialsRead,
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the cluster to get credentials for",
+			},
+
+			"host": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "URL of the Kubernetes API",
+			},
+
+			"cluster_ca_certificate": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "PEM-encoded CA certificate of the Kubernetes API",
+			},
+
+			"token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Bearer token for authenticating to the Kubernetes API",
+			},
+
+			"client_certificate": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "PEM-encoded client certificate for authenticating to the Kubernetes API",
+			},
+
+			"client_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "PEM-encoded client key for authenticating to the Kubernetes API",
+			},
+
+			"kubeconfig": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Kubeconfig for the cluster, in the JSON format accepted by kubectl",
+			},
+		},
+	}
+}
+
+func dataSourcePksClusterCredentialsRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	clusterName := d.Get("cluster_name").(string)
+
+	kc, err := pksClient.api.GetClusterCredentials(pksClient.stopCtx, clusterName)
+	if pksapi.IsNotFound(err) {
+		return fmt.Errorf("Cluster %q not found in PKS", clusterName)
+	} else if err != nil {
+		return err
+	}
+
+	if len(kc.Clusters) == 0 || len(kc.Users) == 0 {
+		return fmt.Errorf("credentials for cluster %q are missing cluster or user details", clusterName)
+	}
+	cluster := kc.Clusters[0].Cluster
+	user := kc.Users[0].User
+
+	caCert, err := base64.StdEncoding.DecodeString(cluster.CertificateAuthorityData)
+	if err != nil {
+		return fmt.Errorf("error decoding CA certificate for cluster %q: %q", clusterName, err.Error())
+	}
+	clientCert, err := base64.StdEncoding.DecodeString(user.ClientCertificateData)
+	if err != nil {
+		return fmt.Errorf("error decoding client certificate for cluster %q: %q", clusterName, err.Error())
+	}
+	clientKey, err := base64.StdEncoding.DecodeString(user.ClientKeyData)
+	if err != nil {
+		return fmt.Errorf("error decoding client key for cluster %q: %q", clusterName, err.Error())
+	}
+
+	kubeconfig, err := json.MarshalIndent(kc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error rendering kubeconfig for cluster %q: %q", clusterName, err.Error())
+	}
+
+	d.SetId(clusterName)
+	d.Set("host", cluster.Server)
+	d.Set("cluster_ca_certificate", string(caCert))
+	d.Set("token", user.Token)
+	d.Set("client_certificate", string(clientCert))
+	d.Set("client_key", string(clientKey))
+	d.Set("kubeconfig", string(kubeconfig))
+
+	return nil
+}
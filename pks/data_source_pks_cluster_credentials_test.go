@@ -0,0 +1,45 @@
+package pks
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"testing"
+)
+
+func TestAccDataSourcePksClusterCredentials_basic(t *testing.T) {
+	rString := acctest.RandString(6)
+	dataSourceName := "data.pks_cluster_credentials.test"
+	clusterName := "tf_acc_creds_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourcePksClusterCredentialsConfig(clusterName, hostname),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "host", "https://"+hostname+":8443"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "cluster_ca_certificate"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "kubeconfig"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourcePksClusterCredentialsConfig(name, hostname string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+}
+
+data "pks_cluster_credentials" "test" {
+  cluster_name = pks_cluster.test.name
+}
+`, name, hostname)
+}
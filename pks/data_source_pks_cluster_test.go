@@ -0,0 +1,70 @@
+package pks
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"regexp"
+	"testing"
+)
+
+func TestAccDataSourcePksCluster_basic(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	dataSourceName := "data.pks_cluster.test"
+	clusterName := "tf_acc_ds_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourcePksClusterConfig(clusterName, hostname),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(dataSourceName, "name", resourceName, "name"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "uuid", resourceName, "uuid"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "external_hostname", resourceName, "external_hostname"),
+					resource.TestCheckResourceAttrPair(dataSourceName, "master_ips.#", resourceName, "master_ips.#"),
+					resource.TestCheckResourceAttr(dataSourceName, "last_action_state", "succeeded"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourcePksCluster_missing(t *testing.T) {
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccDataSourcePksClusterMissingConfig("tf_acc_ds_missing_" + acctest.RandString(6)),
+				ExpectError: regexp.MustCompile("not found in PKS"),
+			},
+		},
+	})
+}
+
+func testAccDataSourcePksClusterConfig(name, hostname string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+}
+
+data "pks_cluster" "test" {
+  name = pks_cluster.test.name
+}
+`, name, hostname)
+}
+
+func testAccDataSourcePksClusterMissingConfig(name string) string {
+	return fmt.Sprintf(`
+data "pks_cluster" "test" {
+  name = "%s"
+}
+`, name)
+}
@@ -0,0 +1,98 @@
+package pks
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"strconv"
+	"strings"
+)
+
+func dataSourcePksPlans() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePksPlansRead,
+
+		Schema: map[string]*schema.Schema{
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Names of all plans available for creating clusters",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"plans": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"worker_instances": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Default number of worker nodes for clusters using the plan",
+						},
+
+						"max_worker_instances": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Maximum number of worker nodes for clusters using the plan",
+						},
+
+						"master_instances": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of master nodes for clusters using the plan",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePksPlansRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+
+	plans, err := pksClient.api.GetPlans(pksClient.stopCtx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(plans))
+	planList := make([]interface{}, 0, len(plans))
+	for _, plan := range plans {
+		names = append(names, plan.Name)
+		planList = append(planList, map[string]interface{}{
+			"id":                   plan.ID,
+			"name":                 plan.Name,
+			"description":          plan.Description,
+			"worker_instances":     plan.WorkerInstances,
+			"max_worker_instances": plan.MaxWorkerInstances,
+			"master_instances":     plan.MasterInstances,
+		})
+	}
+
+	// the set of plans is global to the PKS installation, so the ID only needs to change along with it
+	d.SetId(strconv.Itoa(hashcode.String(strings.Join(names, ","))))
+	if err := d.Set("names", names); err != nil {
+		return err
+	}
+	if err := d.Set("plans", planList); err != nil {
+		return err
+	}
+
+	return nil
+}
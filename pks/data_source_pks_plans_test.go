@@ -0,0 +1,36 @@
+package pks
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"testing"
+)
+
+func TestAccDataSourcePksPlans_basic(t *testing.T) {
+	dataSourceName := "data.pks_plans.test"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourcePksPlansConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(dataSourceName, "plans.0.name"),
+					resource.TestCheckResourceAttrSet(dataSourceName, "plans.0.worker_instances"),
+					// the cluster acceptance tests use the small plan, so it must be there
+					resource.TestCheckOutput("has_small", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourcePksPlansConfig() string {
+	return `
+data "pks_plans" "test" {}
+
+output "has_small" {
+  value = tostring(contains(data.pks_plans.test.names, "small"))
+}
+`
+}
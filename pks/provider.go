@@ -1,19 +1,30 @@
 package pks
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/logging"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// Client is the provider's meta value, shared by all resources and data sources
 type Client struct {
-	hostname, token, clientId, clientSecret, username, password string
-	httpClient                                                  *http.Client
-	maxWaitMin, waitPollIntervalSec                             int64
+	// resources only use PKS through the API interface, so it can be replaced in tests
+	api        pksapi.API
+	maxWaitMin int64
+
+	// stopCtx is cancelled when terraform is interrupted, so that requests and waits end early
+	stopCtx context.Context
 }
 
 func Provider() terraform.ResourceProvider {
@@ -25,10 +36,24 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("PKS_HOSTNAME", nil),
 			},
 
+			"api_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PKS_API_URL", nil),
+				Description: "Base URL of the PKS API, overriding the default of https://<hostname>:9021",
+			},
+
+			"uaa_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PKS_UAA_URL", nil),
+				Description: "Base URL of the PKS UAA, overriding the default of https://<hostname>:8443",
+			},
+
 			"token": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"client_id", "client_secret"},
+				ConflictsWith: []string{"client_id", "client_secret", "username", "password"},
 				DefaultFunc:   schema.EnvDefaultFunc("PKS_TOKEN", nil),
 				Description:   "Use generated token from UAA in lieu of normal auth",
 			},
@@ -36,29 +61,32 @@ func Provider() terraform.ResourceProvider {
 			"client_id": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"token"},
+				ConflictsWith: []string{"token", "username", "password"},
 				DefaultFunc:   schema.EnvDefaultFunc("PKS_CLIENT_ID", nil),
 			},
 
 			"client_secret": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"token"},
+				ConflictsWith: []string{"token", "username", "password"},
 				DefaultFunc:   schema.EnvDefaultFunc("PKS_CLIENT_SECRET", nil),
 			},
 
-			/* TODO (check whats the supported service client auth flow for pks api
-			"username": &schema.Schema{
-				Type:        schema.TypeString,
-				Optional:    true,
-				ConflictsWith: []string{"client_id", "client_secret", "token"},
+			"username": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"token", "client_id", "client_secret"},
+				DefaultFunc:   schema.EnvDefaultFunc("PKS_USERNAME", nil),
+				Description:   "UAA user to login as, the same as used with pks login",
 			},
 
-			"password": &schema.Schema{
-				Type:        schema.TypeString,
-				Optional:    true,
-				ConflictsWith: []string{"client_id", "client_secret", "token"},
-			},*/
+			"password": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"token", "client_id", "client_secret"},
+				DefaultFunc:   schema.EnvDefaultFunc("PKS_PASSWORD", nil),
+			},
 
 			"skip_ssl_validation": {
 				Type:        schema.TypeBool,
@@ -66,6 +94,29 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("PKS_SKIP_SSL_VALIDATION", false),
 			},
 
+			"ca_cert": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+				DefaultFunc:   schema.EnvDefaultFunc("PKS_CA_CERT", nil),
+				Description:   "PEM-encoded CA certificate(s) used to verify the PKS API and UAA",
+			},
+
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert"},
+				DefaultFunc:   schema.EnvDefaultFunc("PKS_CA_CERT_FILE", nil),
+				Description:   "Path to a file of PEM-encoded CA certificate(s) used to verify the PKS API and UAA",
+			},
+
+			"tls_server_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PKS_TLS_SERVER_NAME", nil),
+				Description: "Name to verify the PKS API and UAA certificates against, if it differs from the hostname in the URL",
+			},
+
 			"max_wait_min": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -79,57 +130,148 @@ func Provider() terraform.ResourceProvider {
 				Description: "Frequency of polling (in seconds) while waiting for async operations like cluster creation",
 				DefaultFunc: schema.EnvDefaultFunc("PKS_WAIT_POLL_INTERVAL_SEC", 10),
 			},
+
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Max number of times a request to PKS will be retried after a network error or a transient error from the API",
+				DefaultFunc:  schema.EnvDefaultFunc("PKS_MAX_RETRIES", 5),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"retry_max_wait_sec": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Max length of time (in seconds) to wait between retries of a request to PKS",
+				DefaultFunc:  schema.EnvDefaultFunc("PKS_RETRY_MAX_WAIT_SEC", 30),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"pks_cluster": resourcePksCluster(),
-			/* TODO
-			"pks_network_profile": resourcePksNetworkProfile(),
-			"pks_sink": resourcePksSink(),
-			*/
+			"pks_cluster":                      resourcePksCluster(),
+			"pks_network_profile":              resourcePksNetworkProfile(),
+			"pks_compute_profile":              resourcePksComputeProfile(),
+			"pks_kubernetes_profile":           resourcePksKubernetesProfile(),
+			"pks_sink":                         resourcePksSink(),
+			"pks_cluster_certificate_rotation": resourcePksClusterCertificateRotation(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"pks_cluster":             dataSourcePksCluster(),
+			"pks_cluster_credentials": dataSourcePksClusterCredentials(),
+			"pks_plans":               dataSourcePksPlans(),
 		},
-		ConfigureFunc: providerConfigure,
+	}
+	provider.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
+		return providerConfigure(d, provider.StopContext())
 	}
 	return provider
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func providerConfigure(d *schema.ResourceData, stopCtx context.Context) (interface{}, error) {
+	tlsConfig, err := providerTLSConfig(d)
+	if err != nil {
+		return nil, err
+	}
+
 	c := cleanhttp.DefaultClient()
 	if d.Get("skip_ssl_validation").(bool) {
+		if tlsConfig != nil {
+			return nil, fmt.Errorf("`skip_ssl_validation` can't be used along with `ca_cert`, `ca_cert_file` or `tls_server_name`")
+		}
 		tr := &http.Transport{
 			TLSClientConfig:    &tls.Config{InsecureSkipVerify: true},
 			DisableCompression: true,
 		}
 		c.Transport = logging.NewTransport("pks", tr)
+	} else if tlsConfig != nil {
+		tr := cleanhttp.DefaultTransport()
+		tr.TLSClientConfig = tlsConfig
+		c.Transport = logging.NewTransport("pks", tr)
 	} else {
 		c.Transport = logging.NewTransport("pks", c.Transport)
 	}
 
 	hostname := d.Get("hostname").(string)
+	apiUrl := strings.TrimSuffix(d.Get("api_url").(string), "/")
+	uaaUrl := strings.TrimSuffix(d.Get("uaa_url").(string), "/")
+	if hostname == "" && (apiUrl == "" || uaaUrl == "") {
+		return nil, fmt.Errorf("`hostname` must be set unless both `api_url` and `uaa_url` are set")
+	}
+	if apiUrl == "" {
+		apiUrl = "https://" + hostname + ":9021"
+	}
+	if uaaUrl == "" {
+		uaaUrl = "https://" + hostname + ":8443"
+	}
+
+	opts := []pksapi.Option{
+		pksapi.WithBaseURL(apiUrl),
+		pksapi.WithUAAURL(uaaUrl),
+		pksapi.WithHTTPClient(c),
+		pksapi.WithRetries(int64(d.Get("max_retries").(int)), time.Second, time.Duration(d.Get("retry_max_wait_sec").(int))*time.Second),
+		pksapi.WithPollInterval(time.Duration(d.Get("wait_poll_interval_sec").(int)) * time.Second),
+	}
 
 	// make sure we have a token via one of the auth methods
 	clientId, clientIdOk := d.GetOk("client_id")
 	clientSecret, clientSecretOk := d.GetOk("client_secret")
+	username, usernameOk := d.GetOk("username")
+	password, passwordOk := d.GetOk("password")
 	token, tokenOk := d.GetOk("token")
-	var clientToken string
-	var err error
 	if clientIdOk && clientSecretOk {
-		clientToken, err = ClientLogin(c, hostname, clientId.(string), clientSecret.(string))
-		if err != nil {
-			return nil, err
-		}
+		opts = append(opts, pksapi.WithClientCredentials(clientId.(string), clientSecret.(string)))
+	} else if usernameOk && passwordOk {
+		opts = append(opts, pksapi.WithUserCredentials(username.(string), password.(string)))
 	} else if tokenOk {
-		clientToken = token.(string)
+		opts = append(opts, pksapi.WithToken(token.(string)))
 	} else {
-		return nil, fmt.Errorf("no valid combination of auth attributes found, set `token` OR both `client_id` and `client_secret`")
+		return nil, fmt.Errorf("no valid combination of auth attributes found, set `token` OR both `client_id` and `client_secret` OR both `username` and `password`")
+	}
+
+	api, err := pksapi.NewClient(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	om := &Client{
-		hostname:            hostname,
-		token:               clientToken,
-		httpClient:          c,
-		maxWaitMin:          int64(d.Get("max_wait_min").(int)),
-		waitPollIntervalSec: int64(d.Get("wait_poll_interval_sec").(int)),
+	// login straight away so bad credentials are reported early, the token will be refreshed as it expires
+	if err := api.Login(stopCtx); err != nil {
+		return nil, err
 	}
 
-	return om, nil
+	return &Client{
+		api:        api,
+		maxWaitMin: int64(d.Get("max_wait_min").(int)),
+		stopCtx:    stopCtx,
+	}, nil
+}
+
+// providerTLSConfig returns nil when no TLS settings are given, so the system defaults are used
+func providerTLSConfig(d *schema.ResourceData) (*tls.Config, error) {
+	caCert := d.Get("ca_cert").(string)
+	if caCertFile := d.Get("ca_cert_file").(string); caCertFile != "" {
+		b, err := ioutil.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading `ca_cert_file` %q: %q", caCertFile, err.Error())
+		}
+		caCert = string(b)
+	}
+	serverName := d.Get("tls_server_name").(string)
+
+	if caCert == "" && serverName == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: serverName}
+	if caCert != "" {
+		// only trust the given CAs, so certificates from any other CA are rejected
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM([]byte(caCert)) {
+			return nil, fmt.Errorf("no valid PEM-encoded certificates found in the CA certificate")
+		}
+	}
+	return tlsConfig, nil
+}
+
+func (client *Client) maxWait() time.Duration {
+	return time.Duration(client.maxWaitMin) * time.Minute
 }
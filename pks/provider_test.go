@@ -1,9 +1,14 @@
 package pks
 
 import (
+	"context"
+	"encoding/pem"
+	"github.com/benjvi/terraform-provider-pks/pksapi/pksapitest"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -11,6 +16,12 @@ import (
 var testAccProviders map[string]terraform.ResourceProvider
 var testAccProvider *schema.Provider
 
+// testAccUpgradePksVersion is the version the fake PKS upgrades clusters to
+const testAccUpgradePksVersion = "1.7.0"
+
+// testAccFakeServer is the fake PKS the acceptance tests run against, when PKS_FAKE_API is set
+var testAccFakeServer *pksapitest.Server
+
 func init() {
 	testAccProvider = Provider().(*schema.Provider)
 	testAccProviders = map[string]terraform.ResourceProvider{
@@ -18,6 +29,30 @@ func init() {
 	}
 }
 
+// TestMain runs the acceptance tests against an in-memory fake of PKS when PKS_FAKE_API is set,
+// so they can be run without a PKS foundation
+func TestMain(m *testing.M) {
+	if os.Getenv("PKS_FAKE_API") == "" {
+		os.Exit(m.Run())
+	}
+
+	server := pksapitest.NewServer()
+	server.UpgradeVersion = testAccUpgradePksVersion
+	testAccFakeServer = server
+	for _, k := range []string{"PKS_HOSTNAME", "PKS_TOKEN", "PKS_USERNAME", "PKS_PASSWORD", "PKS_CA_CERT", "PKS_CA_CERT_FILE"} {
+		os.Unsetenv(k)
+	}
+	os.Setenv("PKS_API_URL", server.URL)
+	os.Setenv("PKS_UAA_URL", server.URL)
+	os.Setenv("PKS_CLIENT_ID", pksapitest.ClientID)
+	os.Setenv("PKS_CLIENT_SECRET", pksapitest.ClientSecret)
+	os.Setenv("PKS_WAIT_POLL_INTERVAL_SEC", "1")
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -28,11 +63,77 @@ func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
 
+func TestProvider_caCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "small"}]`))
+	}))
+	defer server.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	testCases := map[string]struct {
+		config    map[string]interface{}
+		expectErr bool
+	}{
+		"trusted CA": {
+			config: map[string]interface{}{"ca_cert": string(caCert)},
+		},
+		"server name override": {
+			// the test server certificate is valid for example.com
+			config: map[string]interface{}{"ca_cert": string(caCert), "tls_server_name": "example.com"},
+		},
+		"wrong server name": {
+			config:    map[string]interface{}{"ca_cert": string(caCert), "tls_server_name": "pks.example.org"},
+			expectErr: true,
+		},
+		"system CAs": {
+			config:    map[string]interface{}{},
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := map[string]interface{}{
+				"api_url": server.URL,
+				"uaa_url": server.URL,
+				"token":   "test-token",
+				// stop credentials in the environment taking precedence over the token
+				"client_id": "",
+				"username":  "",
+			}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+
+			d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, config)
+			m, err := providerConfigure(d, context.Background())
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			_, err = m.(*Client).api.GetPlans(context.Background())
+			if tc.expectErr && err == nil {
+				t.Fatal("expected certificate verification to fail")
+			} else if !tc.expectErr && err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		})
+	}
+}
+
 type preCheckFunc = func(*testing.T)
 
 func testAccPreCheck(t *testing.T) {
-	if v := os.Getenv("PKS_HOSTNAME"); v == "" {
-		t.Fatal("PKS_HOSTNAME must be set for acceptance tests")
+	if os.Getenv("PKS_HOSTNAME") == "" && (os.Getenv("PKS_API_URL") == "" || os.Getenv("PKS_UAA_URL") == "") {
+		t.Fatal("PKS_HOSTNAME, or both PKS_API_URL and PKS_UAA_URL, must be set for acceptance tests")
+	}
+}
+
+// testAccPreCheckFake skips tests that change clusters behind the provider's back, which only the fake PKS allows
+func testAccPreCheckFake(t *testing.T) {
+	testAccPreCheck(t)
+	if testAccFakeServer == nil {
+		t.Skip("PKS_FAKE_API must be set for tests that simulate failed cluster actions")
 	}
 }
 
@@ -1,8 +1,16 @@
 package pks
 
 import (
+	"fmt"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 func resourcePksCluster() *schema.Resource {
@@ -14,6 +22,26 @@ func resourcePksCluster() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
+		// zero timeouts mean the provider's max_wait_min is used
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Duration(0)),
+			Update: schema.DefaultTimeout(time.Duration(0)),
+			Delete: schema.DefaultTimeout(time.Duration(0)),
+		},
+		CustomizeDiff: customdiff.All(
+			// an upgrade will also move the cluster to a new kubernetes version
+			customdiff.ComputedIf("k8s_version", func(d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChange("pks_version")
+			}),
+			// PKS can change a cluster's network profile, but can't add one to or remove one from a cluster
+			customdiff.ForceNewIfChange("network_profile_name", func(old, new, m interface{}) bool {
+				return old.(string) == "" || new.(string) == ""
+			}),
+			validateClusterPksVersion,
+			validateClusterNodePools,
+			planFailedClusterAction,
+			planInProgressClusterAction,
+		),
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -38,10 +66,85 @@ func resourcePksCluster() *schema.Resource {
 			},
 
 			"num_nodes": {
-				Type:        schema.TypeInt,
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				Description:   "Number of worker nodes, overriding plan-specified default",
+				ConflictsWith: []string{"node_pool"},
+			},
+
+			"node_pool": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Computed:      true,
+				Description:   "Overrides for the node pools defined by the cluster's compute profile",
+				ConflictsWith: []string{"num_nodes"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the node pool in the compute profile",
+						},
+
+						"instances": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							Description:  "Number of worker VMs in the pool",
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+
+						"node_labels": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"node_taints": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Computed:    true,
+							Description: "Taints in the form key=value:effect",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"compute_profile_name": {
+				Type:        schema.TypeString,
 				Optional:    true,
-				Computed:    true,
-				Description: "Number of worker nodes, overriding plan-specified default",
+				Description: "Name of a compute profile to customize the cluster's node pools, instance types and AZs",
+				ForceNew:    true,
+			},
+
+			"kubernetes_profile_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of a kubernetes profile to customize the cluster's Kubernetes components",
+				ForceNew:    true,
+			},
+
+			"network_profile_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of a network profile to customize the cluster's NSX-T networking",
+			},
+
+			"tags": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Tags added to the metadata of the cluster's VMs on the IaaS",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"on_failure": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "retry",
+				Description:  "What to plan when the cluster's last action failed, one of: retry, recreate, error",
+				ValidateFunc: validation.StringInSlice([]string{"retry", "recreate", "error"}, false),
 			},
 
 			"master_ips": {
@@ -63,15 +166,17 @@ func resourcePksCluster() *schema.Resource {
 			},
 
 			"pks_version": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "PKS version of the cluster, set to the version of the PKS installation to upgrade the cluster in place",
 			},
 
 			"last_action": {
 				Type:     schema.TypeString,
 				Computed: true,
 				//ValidateFunc: validation.StringInSlice([]string{"CREATE", "UPDATE", "DELETE"}, true),
-				Description: "Last action performed on the cluster through PKS, one of CREATE, UPDATE, DELETE",
+				Description: "Last action performed on the cluster through PKS, one of CREATE, UPDATE, UPGRADE, DELETE",
 			},
 
 			"last_action_state": {
@@ -96,14 +201,29 @@ func resourcePksClusterCreate(d *schema.ResourceData, m interface{}) error {
 	// 1. setup request object
 	name := d.Get("name").(string)
 
-	params := ClusterParameters{
+	params := pksapi.ClusterParameters{
 		KubernetesMasterHost: d.Get("external_hostname").(string),
 	}
 	if workers, ok := d.GetOk("num_nodes"); ok {
 		params.KubernetesWorkerInstances = int64(workers.(int))
 	}
+	if computeProfile, ok := d.GetOk("compute_profile_name"); ok {
+		params.ComputeProfileName = computeProfile.(string)
+	}
+	if kubernetesProfile, ok := d.GetOk("kubernetes_profile_name"); ok {
+		params.KubernetesProfileName = kubernetesProfile.(string)
+	}
+	if networkProfile, ok := d.GetOk("network_profile_name"); ok {
+		params.NsxtNetworkProfile = networkProfile.(string)
+	}
+	if nodePools, ok := d.GetOk("node_pool"); ok {
+		params.NodePools = expandNodePools(nodePools.([]interface{}))
+	}
+	if tags, ok := d.GetOk("tags"); ok {
+		params.Tags = expandClusterTags(tags.(map[string]interface{}))
+	}
 
-	clusterReq := ClusterRequest{
+	clusterReq := pksapi.ClusterRequest{
 		Parameters: params,
 		Name:       name,
 		PlanName:   d.Get("plan").(string),
@@ -111,18 +231,45 @@ func resourcePksClusterCreate(d *schema.ResourceData, m interface{}) error {
 
 	log.Printf("[DEBUG] PKS cluster create request configuration: %#v", clusterReq)
 
-	err := CreateCluster(pksClient, clusterReq)
-	if err != nil {
+	err := pksClient.api.CreateCluster(pksClient.stopCtx, clusterReq)
+	if pksapi.IsConflict(err) {
+		// an earlier apply may have created this cluster without saving it to the state, e.g. if it crashed
+		cr, exists, getErr := pksClient.api.GetCluster(pksClient.stopCtx, name)
+		if getErr != nil {
+			return getErr
+		}
+		if !exists || !resumableClusterCreate(cr, clusterReq) {
+			return fmt.Errorf("A cluster named %q already exists in PKS, use `terraform import` to manage it with Terraform: %s", name, err)
+		}
+		if strings.EqualFold(cr.LastActionState, "succeeded") {
+			log.Printf("[DEBUG] PKS cluster %q has already been created, adopting it", name)
+			d.SetId(name)
+			return resourcePksClusterRead(d, m)
+		}
+		log.Printf("[DEBUG] PKS cluster %q is already being created, resuming wait", name)
+	} else if err != nil {
 		return err
 	}
 
-	err = WaitForClusterAction(pksClient, name, "CREATE")
-	if err != nil {
+	// Set ID as soon as the cluster exists, so a cluster whose creation fails is saved to the state and tainted.
+	// Only the ID is saved until the next refresh reads the rest
+	d.Partial(true)
+	d.SetId(name)
+
+	err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, "CREATE", clusterActionTimeout(d, schema.TimeoutCreate, pksClient))
+	if pksapi.IsActionFailed(err) {
 		return err
 	}
-
-	// 4. Set ID after success so terraform can continue to manage the resource
-	d.SetId(name)
+	d.Partial(false)
+	if err != nil {
+		// Returning an error would taint the cluster while it may still be being created, so it would be replaced.
+		// Save it as it is instead, the next apply sees it's in progress and resumes waiting for it
+		log.Printf("[WARN] %s. PKS cluster %q may still be being created, the next apply will resume waiting for it", err, name)
+		if pksClient.stopCtx.Err() != nil {
+			// interrupted, so it can't be read. The next refresh reads it
+			return nil
+		}
+	}
 
 	return resourcePksClusterRead(d, m)
 }
@@ -133,7 +280,7 @@ func resourcePksClusterRead(d *schema.ResourceData, m interface{}) error {
 	// in particular, on import only ID is set
 	name := d.Id()
 
-	cr, exists, err := GetCluster(pksClient, name)
+	cr, exists, err := pksClient.api.GetCluster(pksClient.stopCtx, name)
 	if err != nil {
 		return err
 	}
@@ -148,11 +295,26 @@ func resourcePksClusterRead(d *schema.ResourceData, m interface{}) error {
 	d.Set("external_hostname", cr.Parameters.KubernetesMasterHost)
 	d.Set("plan", cr.PlanName)
 	d.Set("num_nodes", cr.Parameters.KubernetesWorkerInstances)
+	d.Set("compute_profile_name", cr.Parameters.ComputeProfileName)
+	d.Set("kubernetes_profile_name", cr.Parameters.KubernetesProfileName)
+	d.Set("network_profile_name", cr.Parameters.NsxtNetworkProfile)
+	if err := d.Set("node_pool", flattenManagedClusterNodePools(cr.Parameters.NodePools, d.Get("node_pool").([]interface{}))); err != nil {
+		return err
+	}
+	if err := d.Set("tags", flattenClusterTags(cr.Parameters.Tags)); err != nil {
+		return err
+	}
 	d.Set("uuid", cr.Uuid)
+	d.Set("k8s_version", cr.K8sVersion)
+	d.Set("pks_version", cr.PksVersion)
 	d.Set("last_action", cr.LastAction)
 	d.Set("last_action_state", cr.LastActionState)
 	d.Set("last_action_description", cr.LastActionDescription)
 	d.Set("master_ips", cr.KubernetesMasterIps)
+	// imported clusters don't have the setting yet
+	if _, ok := d.GetOk("on_failure"); !ok {
+		d.Set("on_failure", "retry")
+	}
 
 	return nil
 }
@@ -161,21 +323,88 @@ func resourcePksClusterUpdate(d *schema.ResourceData, m interface{}) error {
 	pksClient := m.(*Client)
 	name := d.Id()
 
-	updateClusterReq := UpdateClusterParameters{}
+	// the plan leaves last_action_state unknown when the last action failed and should be retried
+	oldState, _ := d.GetChange("last_action_state")
+	lastAction := strings.ToUpper(d.Get("last_action").(string))
+	retryFailed := strings.EqualFold(oldState.(string), "failed")
+
+	// the plan also leaves it unknown when an action was still running, e.g. an update that timed out
+	if strings.EqualFold(oldState.(string), "in progress") {
+		timeoutKey := schema.TimeoutUpdate
+		if lastAction == "CREATE" {
+			timeoutKey = schema.TimeoutCreate
+		}
+		log.Printf("[DEBUG] PKS cluster %q resuming wait for action %s", name, lastAction)
+		err := pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, lastAction, clusterActionTimeout(d, timeoutKey, pksClient))
+		if err != nil {
+			return err
+		}
+	}
+
+	// upgrade first, PKS only upgrades to the version of the installation so other updates can be applied after
+	if d.HasChange("pks_version") || retryFailed && lastAction == "UPGRADE" {
+		err := pksClient.api.UpgradeCluster(pksClient.stopCtx, name)
+		if err != nil {
+			return err
+		}
 
-	updatesFound := false
-	if numNodes, ok := d.GetOk("num_nodes"); ok {
+		err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, "UPGRADE", clusterActionTimeout(d, schema.TimeoutUpdate, pksClient))
+		if err != nil {
+			return err
+		}
+
+		cr, _, err := pksClient.api.GetCluster(pksClient.stopCtx, name)
+		if err != nil {
+			return err
+		}
+		// a failed upgrade is retried without pks_version being set, then there's no version to check against
+		if desired := d.Get("pks_version").(string); d.HasChange("pks_version") && cr != nil && cr.PksVersion != desired {
+			return fmt.Errorf("Cluster %q was upgraded to PKS version %q, not the requested %q. Clusters can only be upgraded to the version of the PKS installation",
+				name, cr.PksVersion, desired)
+		}
+	}
+
+	updateClusterReq := pksapi.UpdateClusterParameters{}
+
+	// retrying a failed update sends all of the updatable settings again
+	retryUpdate := retryFailed && lastAction == "UPDATE"
+	nodePools := d.Get("node_pool").([]interface{})
+
+	updatesFound := retryUpdate
+	if numNodes, ok := d.GetOk("num_nodes"); ok && (d.HasChange("num_nodes") || retryUpdate && len(nodePools) == 0) {
 		updateClusterReq.KubernetesWorkerInstances = int64(numNodes.(int))
 		updatesFound = true
 	}
 
+	if retryUpdate && len(nodePools) > 0 {
+		updateClusterReq.NodePools = expandNodePools(nodePools)
+	} else if d.HasChange("node_pool") {
+		old, new := d.GetChange("node_pool")
+		// only send the pools being changed, so PKS leaves the others alone
+		if changed := changedClusterNodePools(old.([]interface{}), new.([]interface{})); len(changed) > 0 {
+			updateClusterReq.NodePools = changed
+			updatesFound = true
+		}
+	}
+
+	if d.HasChange("network_profile_name") || retryUpdate && d.Get("network_profile_name").(string) != "" {
+		updateClusterReq.NsxtNetworkProfile = d.Get("network_profile_name").(string)
+		updatesFound = true
+	}
+
+	if d.HasChange("tags") || retryUpdate {
+		tags := expandClusterTags(d.Get("tags").(map[string]interface{}))
+		updateClusterReq.Tags = &tags
+		updatesFound = true
+	}
+
 	if updatesFound {
-		err := UpdateCluster(pksClient, name, updateClusterReq)
+		err := pksClient.api.UpdateCluster(pksClient.stopCtx, name, updateClusterReq)
 		if err != nil {
 			return err
 		}
 
-		err = WaitForClusterAction(pksClient, name, "UPDATE")
+		err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, "UPDATE", clusterActionTimeout(d, schema.TimeoutUpdate, pksClient))
 		if err != nil {
 			return err
 		}
@@ -188,15 +417,202 @@ func resourcePksClusterDelete(d *schema.ResourceData, m interface{}) error {
 	pksClient := m.(*Client)
 	name := d.Id()
 
-	err := DeleteCluster(pksClient, name)
+	cr, exists, err := pksClient.api.GetCluster(pksClient.stopCtx, name)
 	if err != nil {
 		return err
 	}
+	if !exists {
+		return nil
+	}
+
+	// the delete timeout covers waiting for any running action as well as the delete itself
+	deadline := time.Now().Add(clusterActionTimeout(d, schema.TimeoutDelete, pksClient))
+
+	// PKS won't delete a cluster while another action is running on it, e.g. an update that timed out.
+	// Wait for it to finish, failing or not, as the cluster is being deleted anyway
+	inProgress := strings.EqualFold(cr.LastActionState, "in progress")
+	if inProgress && !strings.EqualFold(cr.LastAction, "DELETE") {
+		log.Printf("[DEBUG] PKS cluster %q waiting for action %s to finish before deleting", name, cr.LastAction)
+		err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, cr.LastAction, time.Until(deadline))
+		if err != nil && !pksapi.IsActionFailed(err) {
+			return err
+		}
+		inProgress = false
+	}
 
-	err = WaitForClusterAction(pksClient, name, "DELETE")
+	if !inProgress {
+		err = pksClient.api.DeleteCluster(pksClient.stopCtx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, name, "DELETE", time.Until(deadline))
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// clusterActionTimeout returns how long to wait for a cluster action, using the resource's timeouts block if it is set.
+// Clusters imported or created before timeouts were supported have no timeouts in their state, and the SDK would
+// use its own default of 20 minutes for them, so they also fall back to max_wait_min
+func clusterActionTimeout(d *schema.ResourceData, key string, client *Client) time.Duration {
+	// there's no state yet when creating, then the timeouts always come from the config
+	if state := d.State(); state != nil {
+		timeouts, _ := state.Meta[schema.TimeoutKey].(map[string]interface{})
+		if _, ok := timeouts[key]; !ok {
+			return client.maxWait()
+		}
+	}
+
+	if timeout := d.Timeout(key); timeout > 0 {
+		return timeout
+	}
+	return client.maxWait()
+}
+
+func expandClusterTags(m map[string]interface{}) []pksapi.Tag {
+	tags := make([]pksapi.Tag, 0, len(m))
+	for k, v := range m {
+		tags = append(tags, pksapi.Tag{Key: k, Value: v.(string)})
+	}
+	// sort so the request doesn't change between runs
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+func flattenClusterTags(tags []pksapi.Tag) map[string]interface{} {
+	m := make(map[string]interface{}, len(tags))
+	for _, tag := range tags {
+		m[tag.Key] = tag.Value
+	}
+	return m
+}
+
+// validateClusterPksVersion rejects pks_version on new clusters, which PKS always creates at the version of the installation
+func validateClusterPksVersion(d *schema.ResourceDiff, m interface{}) error {
+	if _, ok := d.GetOk("pks_version"); ok && d.Id() == "" {
+		return fmt.Errorf("`pks_version` can't be set when creating a cluster, PKS creates clusters at the version of the PKS installation. Set it once the cluster exists to upgrade it")
+	}
+	return nil
+}
+
+// validateClusterNodePools catches node pool changes PKS would reject, before anything is applied
+func validateClusterNodePools(d *schema.ResourceDiff, m interface{}) error {
+	nodePools := d.Get("node_pool").([]interface{})
+	if len(nodePools) > 0 && d.NewValueKnown("compute_profile_name") && d.Get("compute_profile_name").(string) == "" {
+		return fmt.Errorf("`node_pool` can only be set on clusters with a `compute_profile_name`")
+	}
+
+	if d.Id() == "" || !d.HasChange("node_pool") {
+		return nil
+	}
+	old, new := d.GetChange("node_pool")
+	names := map[string]bool{}
+	for _, v := range new.([]interface{}) {
+		names[v.(map[string]interface{})["name"].(string)] = true
+	}
+	for _, v := range old.([]interface{}) {
+		if name := v.(map[string]interface{})["name"].(string); !names[name] {
+			return fmt.Errorf("node pool %q can't be removed, the node pools of a cluster are set by its compute profile", name)
+		}
+	}
+	return nil
+}
+
+// resumableClusterCreate reports whether an existing cluster is the one requested, created by an apply that stopped
+// before saving it to the state. It may still be being created or already be running
+func resumableClusterCreate(cr *pksapi.ClusterResponse, req pksapi.ClusterRequest) bool {
+	notFailed := strings.EqualFold(cr.LastActionState, "in progress") || strings.EqualFold(cr.LastActionState, "succeeded")
+	return strings.EqualFold(cr.LastAction, "CREATE") && notFailed &&
+		cr.PlanName == req.PlanName && cr.Parameters.KubernetesMasterHost == req.Parameters.KubernetesMasterHost
+}
+
+// planInProgressClusterAction makes the plan wait for an action that was still running when the cluster was last
+// refreshed, e.g. an update that was interrupted. An unfinished delete can only be finished by recreating the cluster
+func planInProgressClusterAction(d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" || !strings.EqualFold(d.Get("last_action_state").(string), "in progress") {
+		return nil
+	}
+
+	if err := d.SetNewComputed("last_action_state"); err != nil {
+		return err
+	}
+	if strings.EqualFold(d.Get("last_action").(string), "DELETE") {
+		return d.ForceNew("last_action_state")
+	}
+	return nil
+}
+
+// planFailedClusterAction makes a cluster whose last action failed show up in the plan, as chosen by on_failure.
+// A failed update or upgrade can be retried in place, anything else needs the cluster to be recreated
+func planFailedClusterAction(d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" || !strings.EqualFold(d.Get("last_action_state").(string), "failed") {
+		return nil
+	}
+
+	action := strings.ToUpper(d.Get("last_action").(string))
+	onFailure := d.Get("on_failure").(string)
+	if onFailure == "error" {
+		return fmt.Errorf("Action %s failed on cluster %q: %q. Fix the cluster in PKS, or set `on_failure` to retry or recreate it",
+			action, d.Id(), d.Get("last_action_description").(string))
+	}
+
+	log.Printf("[DEBUG] PKS cluster %q last action %s failed, planning to %s it", d.Id(), action, onFailure)
+	if err := d.SetNewComputed("last_action_state"); err != nil {
+		return err
+	}
+	if onFailure == "retry" && (action == "UPDATE" || action == "UPGRADE") {
+		return nil
+	}
+	return d.ForceNew("last_action_state")
+}
+
+// flattenClusterNodePools flattens all of the cluster's pools, in the order PKS returns them
+func flattenClusterNodePools(pools []pksapi.NodePool) []interface{} {
+	l := make([]interface{}, 0, len(pools))
+	for _, pool := range pools {
+		l = append(l, map[string]interface{}{
+			"name":        pool.Name,
+			"instances":   pool.Instances,
+			"node_labels": pool.NodeLabels,
+			"node_taints": pool.NodeTaints,
+		})
+	}
+	return l
+}
+
+// flattenManagedClusterNodePools only keeps the pools in managed, those configured or already in the state, in the
+// same order. Any other pools are left to the compute profile, so they don't show as removed in the plan
+func flattenManagedClusterNodePools(pools []pksapi.NodePool, managed []interface{}) []interface{} {
+	byName := map[string]pksapi.NodePool{}
+	for _, pool := range pools {
+		byName[pool.Name] = pool
+	}
+
+	var kept []pksapi.NodePool
+	for _, v := range managed {
+		if pool, ok := byName[v.(map[string]interface{})["name"].(string)]; ok {
+			kept = append(kept, pool)
+		}
+	}
+	return flattenClusterNodePools(kept)
+}
+
+// changedClusterNodePools returns the pools in new that are different in old, or aren't in old at all
+func changedClusterNodePools(old, new []interface{}) []pksapi.NodePool {
+	oldPools := map[string]pksapi.NodePool{}
+	for _, pool := range expandNodePools(old) {
+		oldPools[pool.Name] = pool
+	}
+
+	var changed []pksapi.NodePool
+	for _, pool := range expandNodePools(new) {
+		if oldPool, ok := oldPools[pool.Name]; !ok || !reflect.DeepEqual(oldPool, pool) {
+			changed = append(changed, pool)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,88 @@
+package pks
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"log"
+	"time"
+)
+
+// resourcePksClusterCertificateRotation rotates a cluster's certificates when it is created, so changing
+// its triggers rotates them again
+func resourcePksClusterCertificateRotation() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePksClusterCertificateRotationCreate,
+		Read:   resourcePksClusterCertificateRotationRead,
+		Delete: resourcePksClusterCertificateRotationDelete,
+		// a zero timeout means the provider's max_wait_min is used
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Duration(0)),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the cluster whose certificates are rotated",
+				ForceNew:    true,
+			},
+
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Arbitrary values that rotate the certificates again when changed",
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+
+			"rotated_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time the rotation finished, in RFC 3339 format",
+			},
+		},
+	}
+}
+
+func resourcePksClusterCertificateRotationCreate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	clusterName := d.Get("cluster_name").(string)
+
+	log.Printf("[DEBUG] PKS rotating certificates of cluster %q", clusterName)
+
+	err := pksClient.api.RotateClusterCertificates(pksClient.stopCtx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	err = pksClient.api.WaitForClusterAction(pksClient.stopCtx, clusterName, "UPDATE", clusterActionTimeout(d, schema.TimeoutCreate, pksClient))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(clusterName)
+	d.Set("rotated_at", time.Now().UTC().Format(time.RFC3339))
+
+	return resourcePksClusterCertificateRotationRead(d, m)
+}
+
+func resourcePksClusterCertificateRotationRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+
+	_, exists, err := pksClient.api.GetCluster(pksClient.stopCtx, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// the cluster is gone, so if it's created again its certificates should be rotated again too
+		d.SetId("")
+		return nil
+	}
+
+	return nil
+}
+
+func resourcePksClusterCertificateRotationDelete(d *schema.ResourceData, m interface{}) error {
+	// certificates can't be un-rotated, so there's nothing to do besides removing the rotation from the state
+	return nil
+}
@@ -0,0 +1,79 @@
+package pks
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"testing"
+)
+
+func TestAccPksClusterCertificateRotation_triggers(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster_certificate_rotation.test"
+	clusterName := "tf_acc_rotation_" + rString
+	hostname := clusterName + ".example.com"
+	credentialsName := "data.pks_cluster_credentials.test"
+	var firstRotation, firstCA string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterCertificateRotationConfig(clusterName, hostname, "1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cluster_name", clusterName),
+					resource.TestCheckResourceAttrSet(resourceName, "rotated_at"),
+					resource.TestCheckResourceAttrSet(credentialsName, "cluster_ca_certificate"),
+					func(s *terraform.State) error {
+						firstRotation = s.RootModule().Resources[resourceName].Primary.Attributes["rotated_at"]
+						firstCA = s.RootModule().Resources[credentialsName].Primary.Attributes["cluster_ca_certificate"]
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccPksClusterCertificateRotationConfig(clusterName, hostname, "2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "triggers.generation", "2"),
+					func(s *terraform.State) error {
+						rotatedAt := s.RootModule().Resources[resourceName].Primary.Attributes["rotated_at"]
+						if rotatedAt == firstRotation {
+							return fmt.Errorf("certificates weren't rotated again after the triggers changed, rotated_at is still %q", rotatedAt)
+						}
+						ca := s.RootModule().Resources[credentialsName].Primary.Attributes["cluster_ca_certificate"]
+						if ca == firstCA {
+							return fmt.Errorf("the cluster's CA certificate didn't change after rotating its certificates")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func testAccPksClusterCertificateRotationConfig(name, hostname, generation string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+}
+
+resource "pks_cluster_certificate_rotation" "test" {
+  cluster_name = pks_cluster.test.name
+
+  triggers = {
+    generation = "%s"
+  }
+}
+
+data "pks_cluster_credentials" "test" {
+  # the rotation's id is unknown while it's being replaced, so the credentials are read after it
+  cluster_name = pks_cluster_certificate_rotation.test.id
+}
+`, name, hostname, generation)
+}
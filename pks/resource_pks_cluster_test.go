@@ -1,14 +1,19 @@
 package pks
 
 import (
+	"context"
 	"fmt"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/benjvi/terraform-provider-pks/pksapi/pksapitest"
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
-	"io/ioutil"
-	"net/http"
+	"reflect"
+	"regexp"
 	"testing"
+	"time"
 )
 
 func TestAccPksCluster_basic(t *testing.T) {
@@ -140,6 +145,240 @@ func TestAccPksCluster_update(t *testing.T) {
 	})
 }
 
+func TestAccPksCluster_tags(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_tags_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterTagsConfig(clusterName, hostname, `team = "platform"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "tags.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "tags.team", "platform"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccPksClusterTagsConfig(clusterName, hostname, `team = "apps"
+    env = "test"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "tags.%", "2"),
+					resource.TestCheckResourceAttr(resourceName, "tags.team", "apps"),
+					resource.TestCheckResourceAttr(resourceName, "tags.env", "test"),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPDATE"),
+				),
+			},
+			{
+				Config: testAccPksClusterTagsConfig(clusterName, hostname, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "tags.%", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_networkProfile(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_netprofile_" + rString
+	hostname := clusterName + ".example.com"
+	profilePrefix := "tf_acc_cluster_" + rString
+	var initialUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterNetworkProfileConfig(clusterName, hostname, profilePrefix, "small"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "network_profile_name", profilePrefix+"_small"),
+					func(s *terraform.State) error {
+						initialUuid = s.RootModule().Resources[resourceName].Primary.Attributes["uuid"]
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccPksClusterNetworkProfileConfig(clusterName, hostname, profilePrefix, "medium"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "network_profile_name", profilePrefix+"_medium"),
+					// uuid is unchanged, means this is an in-place update
+					func(s *terraform.State) error {
+						uuidVal := s.RootModule().Resources[resourceName].Primary.Attributes["uuid"]
+						if uuidVal != initialUuid {
+							return fmt.Errorf("uuid changed from %q to %q indicated an unwanted recreation", initialUuid, uuidVal)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccPksClusterNetworkProfileConfig(clusterName, hostname, profilePrefix, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "network_profile_name", ""),
+					// PKS can't remove a network profile, so the cluster must have been recreated
+					func(s *terraform.State) error {
+						uuidVal := s.RootModule().Resources[resourceName].Primary.Attributes["uuid"]
+						if uuidVal == initialUuid {
+							return fmt.Errorf("uuid %q is unchanged after removing the network profile", uuidVal)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_nodePools(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_nodepools_" + rString
+	hostname := clusterName + ".example.com"
+	profileName := "tf_acc_cluster_" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterNodePoolsConfig(clusterName, hostname, profileName, 2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.name", "services"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.instances", "2"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.1.name", "batch"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.1.node_labels.workload", "batch"),
+					resource.TestCheckResourceAttr(resourceName, "num_nodes", "3"),
+				),
+			},
+			{
+				Config: testAccPksClusterNodePoolsConfig(clusterName, hostname, profileName, 4),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.instances", "4"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.1.instances", "1"),
+					resource.TestCheckResourceAttr(resourceName, "num_nodes", "5"),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPDATE"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_partialNodePools(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_partialpools_" + rString
+	hostname := clusterName + ".example.com"
+	profileName := "tf_acc_partial_" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterPartialNodePoolsConfig(clusterName, hostname, profileName, 2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					// the batch pool is left to the compute profile, so isn't in the state
+					resource.TestCheckResourceAttr(resourceName, "node_pool.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.name", "services"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.instances", "2"),
+					resource.TestCheckResourceAttr(resourceName, "num_nodes", "3"),
+				),
+			},
+			{
+				Config: testAccPksClusterPartialNodePoolsConfig(clusterName, hostname, profileName, 4),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "node_pool.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.instances", "4"),
+					resource.TestCheckResourceAttr(resourceName, "num_nodes", "5"),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPDATE"),
+				),
+			},
+		},
+	})
+}
+
+func TestChangedClusterNodePools(t *testing.T) {
+	pool := func(name string, instances int, labels map[string]interface{}) interface{} {
+		if labels == nil {
+			labels = map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"name":        name,
+			"instances":   instances,
+			"node_labels": labels,
+			"node_taints": []interface{}{},
+		}
+	}
+
+	testCases := map[string]struct {
+		old, new []interface{}
+		expected []string
+	}{
+		"no change": {
+			old:      []interface{}{pool("a", 1, nil), pool("b", 2, nil)},
+			new:      []interface{}{pool("a", 1, nil), pool("b", 2, nil)},
+			expected: nil,
+		},
+		"one pool scaled": {
+			old:      []interface{}{pool("a", 1, nil), pool("b", 2, nil)},
+			new:      []interface{}{pool("a", 1, nil), pool("b", 3, nil)},
+			expected: []string{"b"},
+		},
+		"labels changed": {
+			old:      []interface{}{pool("a", 1, map[string]interface{}{"x": "1"}), pool("b", 2, nil)},
+			new:      []interface{}{pool("a", 1, map[string]interface{}{"x": "2"}), pool("b", 2, nil)},
+			expected: []string{"a"},
+		},
+		"reordered": {
+			old:      []interface{}{pool("a", 1, nil), pool("b", 2, nil)},
+			new:      []interface{}{pool("b", 2, nil), pool("a", 1, nil)},
+			expected: nil,
+		},
+		"pool added": {
+			old:      []interface{}{pool("a", 1, nil)},
+			new:      []interface{}{pool("a", 1, nil), pool("b", 2, nil)},
+			expected: []string{"b"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var changed []string
+			for _, p := range changedClusterNodePools(tc.old, tc.new) {
+				changed = append(changed, p.Name)
+			}
+			if !reflect.DeepEqual(changed, tc.expected) {
+				t.Errorf("expected changed pools %v, got %v", tc.expected, changed)
+			}
+		})
+	}
+}
+
 func TestAccPksCluster_CreateAfterManualDestroy(t *testing.T) {
 	rString := acctest.RandString(6)
 
@@ -224,6 +463,450 @@ func TestAccPksCluster_import(t *testing.T) {
 	})
 }
 
+func TestAccPksCluster_upgrade(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_upgrade_" + rString
+	hostname := clusterName + ".example.com"
+	var clusterUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterBasicConfig(clusterName, hostname),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "pks_version", pksapitest.PksVersion),
+					testAccGetPksClusterUuid(resourceName, &clusterUuid),
+				),
+			},
+			{
+				Config: testAccPksClusterPksVersionConfig(clusterName, hostname, testAccUpgradePksVersion),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "pks_version", testAccUpgradePksVersion),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPGRADE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					resource.TestCheckResourceAttrPtr(resourceName, "uuid", &clusterUuid),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_upgradeVersionMismatch(t *testing.T) {
+	rString := acctest.RandString(6)
+	clusterName := "tf_acc_upgrademismatch_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterBasicConfig(clusterName, hostname),
+			},
+			{
+				Config:      testAccPksClusterPksVersionConfig(clusterName, hostname, "1.8.0"),
+				ExpectError: regexp.MustCompile(`was upgraded to PKS version "` + testAccUpgradePksVersion + `", not the requested "1.8.0"`),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_pksVersionOnCreate(t *testing.T) {
+	rString := acctest.RandString(6)
+	clusterName := "tf_acc_createversion_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccPksClusterPksVersionConfig(clusterName, hostname, "1.7.0"),
+				ExpectError: regexp.MustCompile("`pks_version` can't be set when creating a cluster"),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_timeouts(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_timeouts_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterTimeoutsConfig(clusterName, hostname, 1, "30m"),
+				Check:  resource.TestCheckResourceAttr(resourceName, "num_nodes", "1"),
+			},
+			{
+				// the fake takes longer than this to update the cluster
+				Config:      testAccPksClusterTimeoutsConfig(clusterName, hostname, 2, "1s"),
+				ExpectError: regexp.MustCompile(`Timed out waiting for action "UPDATE" to succeed`),
+			},
+		},
+	})
+}
+
+func TestClusterActionTimeout(t *testing.T) {
+	client := &Client{maxWaitMin: 7}
+
+	r := resourcePksCluster()
+	r.Timeouts.Update = schema.DefaultTimeout(30 * time.Minute)
+	d := r.Data(nil)
+
+	if timeout := clusterActionTimeout(d, schema.TimeoutUpdate, client); timeout != 30*time.Minute {
+		t.Errorf("expected the update timeout of 30m, got %s", timeout)
+	}
+	if timeout := clusterActionTimeout(d, schema.TimeoutCreate, client); timeout != 7*time.Minute {
+		t.Errorf("expected the create timeout to fall back to max_wait_min of 7m, got %s", timeout)
+	}
+}
+
+// waitRecordingAPI is a cluster that's always there, recording the waits on its actions.
+// The rest of the API isn't implemented
+type waitRecordingAPI struct {
+	pksapi.API
+	waits []time.Duration
+	// updating leaves an update running on the cluster, each wait for it taking waitTime
+	updating bool
+	waitTime time.Duration
+}
+
+func (api *waitRecordingAPI) GetCluster(ctx context.Context, clusterName string) (*pksapi.ClusterResponse, bool, error) {
+	if api.updating {
+		return &pksapi.ClusterResponse{Name: clusterName, PlanName: "small", LastAction: "UPDATE", LastActionState: "in progress"}, true, nil
+	}
+	return &pksapi.ClusterResponse{Name: clusterName, PlanName: "small", LastAction: "CREATE", LastActionState: "succeeded"}, true, nil
+}
+
+func (api *waitRecordingAPI) DeleteCluster(ctx context.Context, clusterName string) error {
+	return nil
+}
+
+func (api *waitRecordingAPI) WaitForClusterAction(ctx context.Context, clusterName, action string, maxWait time.Duration) error {
+	api.waits = append(api.waits, maxWait)
+	if action == "UPDATE" {
+		time.Sleep(api.waitTime)
+		api.updating = false
+	}
+	return nil
+}
+
+func TestResourcePksClusterDelete_timeouts(t *testing.T) {
+	testCases := map[string]struct {
+		meta         map[string]interface{}
+		expectedWait time.Duration
+	}{
+		"imported, without timeouts in the state": {
+			expectedWait: 90 * time.Minute,
+		},
+		"delete timeout set": {
+			meta:         map[string]interface{}{schema.TimeoutDelete: int64(45 * time.Minute)},
+			expectedWait: 45 * time.Minute,
+		},
+		"delete timeout left to max_wait_min": {
+			meta:         map[string]interface{}{schema.TimeoutDelete: int64(0)},
+			expectedWait: 90 * time.Minute,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			api := &waitRecordingAPI{}
+			client := &Client{api: api, maxWaitMin: 90, stopCtx: context.Background()}
+			r := resourcePksCluster()
+
+			state := &terraform.InstanceState{ID: "my-cluster"}
+			if tc.meta != nil {
+				state.Meta = map[string]interface{}{schema.TimeoutKey: tc.meta}
+			}
+			// refresh the state the same way as after an import, before destroying it
+			state, err := r.Refresh(state, client)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if _, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, client); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			// the wait gets what's left of the timeout by then, which is a little less
+			if len(api.waits) != 1 || api.waits[0] > tc.expectedWait || api.waits[0] < tc.expectedWait-time.Second {
+				t.Errorf("expected a single wait of about %s, got %v", tc.expectedWait, api.waits)
+			}
+		})
+	}
+}
+
+func TestResourcePksClusterDelete_runningAction(t *testing.T) {
+	api := &waitRecordingAPI{updating: true, waitTime: 50 * time.Millisecond}
+	client := &Client{api: api, maxWaitMin: 90, stopCtx: context.Background()}
+	r := resourcePksCluster()
+
+	state := &terraform.InstanceState{
+		ID:   "my-cluster",
+		Meta: map[string]interface{}{schema.TimeoutKey: map[string]interface{}{schema.TimeoutDelete: int64(time.Minute)}},
+	}
+	state, err := r.Refresh(state, client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, client); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// waiting for the update and then the delete share the delete timeout
+	if len(api.waits) != 2 {
+		t.Fatalf("expected to wait for the update and then the delete, got %v", api.waits)
+	}
+	if api.waits[0] > time.Minute || api.waits[1] > time.Minute-api.waitTime {
+		t.Errorf("expected the waits to fit in the delete timeout of 1m, got %v", api.waits)
+	}
+}
+
+func TestAccPksCluster_retryFailedUpdate(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_retryupdate_" + rString
+	hostname := clusterName + ".example.com"
+	var clusterUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterOnFailureConfig(clusterName, hostname, "retry"),
+				Check:  testAccGetPksClusterUuid(resourceName, &clusterUuid),
+			},
+			{
+				PreConfig: testAccFailPksClusterAction(t, clusterName, "UPDATE"),
+				Config:    testAccPksClusterOnFailureConfig(clusterName, hostname, "retry"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPDATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					resource.TestCheckResourceAttrPtr(resourceName, "uuid", &clusterUuid),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_retryFailedUpgrade(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_retryupgrade_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterBasicConfig(clusterName, hostname),
+			},
+			{
+				// pks_version isn't in the config, so the upgrade is retried without a version to check
+				PreConfig: testAccFailPksClusterAction(t, clusterName, "UPGRADE"),
+				Config:    testAccPksClusterBasicConfig(clusterName, hostname),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPGRADE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					resource.TestCheckResourceAttr(resourceName, "pks_version", testAccUpgradePksVersion),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_recreateFailedCreate(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_recreate_" + rString
+	hostname := clusterName + ".example.com"
+	var clusterUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterOnFailureConfig(clusterName, hostname, "retry"),
+				Check:  testAccGetPksClusterUuid(resourceName, &clusterUuid),
+			},
+			{
+				PreConfig: testAccFailPksClusterAction(t, clusterName, "CREATE"),
+				Config:    testAccPksClusterOnFailureConfig(clusterName, hostname, "retry"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "last_action", "CREATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					func(s *terraform.State) error {
+						if newUuid := s.RootModule().Resources[resourceName].Primary.Attributes["uuid"]; newUuid == clusterUuid {
+							return fmt.Errorf("cluster wasn't recreated after its creation failed, uuid is still %q", newUuid)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_errorOnFailure(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_onfailure_" + rString
+	hostname := clusterName + ".example.com"
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterOnFailureConfig(clusterName, hostname, "error"),
+				Check:  resource.TestCheckResourceAttr(resourceName, "on_failure", "error"),
+			},
+			{
+				PreConfig:   testAccFailPksClusterAction(t, clusterName, "UPDATE"),
+				Config:      testAccPksClusterOnFailureConfig(clusterName, hostname, "error"),
+				ExpectError: regexp.MustCompile(`Action UPDATE failed on cluster`),
+			},
+			{
+				Config: testAccPksClusterOnFailureConfig(clusterName, hostname, "retry"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "last_action", "UPDATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_createTimeout(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_createtimeout_" + rString
+	hostname := clusterName + ".example.com"
+	var clusterUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				// the apply stops waiting, but the cluster is saved to the state rather than tainted
+				Config: testAccPksClusterCreateTimeoutConfig(clusterName, hostname, "1s"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "CREATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "in progress"),
+					testAccGetPksClusterUuid(resourceName, &clusterUuid),
+				),
+				// the plan waits for the create, unless it has finished by then
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// the next apply picks up the same cluster once it's created
+				Config: testAccPksClusterCreateTimeoutConfig(clusterName, hostname, "10m"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksClusterExists(resourceName, clusterName),
+					resource.TestCheckResourceAttr(resourceName, "last_action", "CREATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					resource.TestCheckResourceAttrPtr(resourceName, "uuid", &clusterUuid),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPksCluster_adoptUnsavedCreate(t *testing.T) {
+	testCases := map[string]bool{
+		"still being created": false,
+		"already created":     true,
+	}
+
+	for name, waitForCreate := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rString := acctest.RandString(6)
+			resourceName := "pks_cluster.test"
+			clusterName := "tf_acc_adoptcreate_" + rString
+			hostname := clusterName + ".example.com"
+			var clusterUuid string
+
+			resource.ParallelTest(t, resource.TestCase{
+				PreCheck:     func() { testAccPreCheckFake(t) },
+				Providers:    testAccProviders,
+				CheckDestroy: testAccCheckPksClusterDestroy,
+				Steps: []resource.TestStep{
+					{
+						// as if an earlier apply had crashed before saving the cluster to the state
+						PreConfig: func() {
+							clusterUuid = testAccCreatePksClusterOutOfBand(t, pksapi.ClusterRequest{
+								Name:       clusterName,
+								PlanName:   "small",
+								Parameters: pksapi.ClusterParameters{KubernetesMasterHost: hostname},
+							}, waitForCreate)
+						},
+						Config: testAccPksClusterBasicConfig(clusterName, hostname),
+						Check: resource.ComposeTestCheckFunc(
+							testAccCheckPksClusterExists(resourceName, clusterName),
+							resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+							resource.TestCheckResourceAttrPtr(resourceName, "uuid", &clusterUuid),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestAccPksCluster_resumeCreate(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_cluster.test"
+	clusterName := "tf_acc_resumecreate_" + rString
+	hostname := clusterName + ".example.com"
+	var clusterUuid string
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckFake(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksClusterBasicConfig(clusterName, hostname),
+				Check:  testAccGetPksClusterUuid(resourceName, &clusterUuid),
+			},
+			{
+				// as if the cluster had been imported while it was still being created
+				PreConfig: func() {
+					testAccStillCreatingPksCluster(t, clusterName)
+				},
+				Config: testAccPksClusterBasicConfig(clusterName, hostname),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "last_action", "CREATE"),
+					resource.TestCheckResourceAttr(resourceName, "last_action_state", "succeeded"),
+					resource.TestCheckResourceAttrPtr(resourceName, "uuid", &clusterUuid),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckPksClusterExists(resourceName, clusterName string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[resourceName]
@@ -236,7 +919,7 @@ func testAccCheckPksClusterExists(resourceName, clusterName string) resource.Tes
 		}
 
 		client := testAccProvider.Meta().(*Client)
-		cr, _, err := GetCluster(client, clusterName)
+		cr, _, err := client.api.GetCluster(context.Background(), clusterName)
 		if err != nil {
 			return err
 		}
@@ -257,20 +940,12 @@ func testAccCheckPksClusterDestroy(s *terraform.State) error {
 			continue
 		}
 
-		req, _ := http.NewRequest("GET", "https://"+client.hostname+":9021/v1/clusters/"+rs.Primary.ID, nil)
-		req.Header["Authorization"] = []string{"Bearer " + client.token}
-		req.Header["Accept"] = []string{"application/json; charset=utf-8"}
-		resp, err := client.httpClient.Do(req)
+		_, exists, err := client.api.GetCluster(context.Background(), rs.Primary.ID)
 		if err != nil {
 			return fmt.Errorf("Error checking cluster %q is destroyed: %q", rs.Primary.ID, err.Error())
 		}
-		if resp.StatusCode == 404 {
-			resp.Body.Close()
-			return nil
-		} else {
-			body, _ := ioutil.ReadAll(resp.Body)
-			resp.Body.Close()
-			return fmt.Errorf("unexpected response %q after cluster %q destruction: %q", resp.Status, rs.Primary.ID, body)
+		if exists {
+			return fmt.Errorf("cluster %q still exists after destruction", rs.Primary.ID)
 		}
 	}
 	return nil
@@ -279,12 +954,12 @@ func testAccCheckPksClusterDestroy(s *terraform.State) error {
 func testAccManuallyDeletePksCluster(clusterName string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		client := testAccProvider.Meta().(*Client)
-		err := DeleteCluster(client, clusterName)
+		err := client.api.DeleteCluster(context.Background(), clusterName)
 		if err != nil {
 			return err
 		}
 
-		err = WaitForClusterAction(client, clusterName, "DELETE")
+		err = client.api.WaitForClusterAction(context.Background(), clusterName, "DELETE", client.maxWait())
 		if err != nil {
 			return err
 		}
@@ -293,6 +968,65 @@ func testAccManuallyDeletePksCluster(clusterName string) resource.TestCheckFunc
 	}
 }
 
+// testAccFailPksClusterAction makes the fake PKS report that the cluster's last action failed
+func testAccFailPksClusterAction(t *testing.T, clusterName, action string) func() {
+	return func() {
+		err := testAccFakeServer.SetClusterState(clusterName, action, "failed", "Instance provisioning failed")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testAccStillCreatingPksCluster makes the fake PKS report that the cluster is still being created, for another
+// ActionDuration, and returns its uuid
+func testAccStillCreatingPksCluster(t *testing.T, clusterName string) string {
+	if err := testAccFakeServer.StartClusterAction(clusterName, "CREATE"); err != nil {
+		t.Fatal(err)
+	}
+	client := testAccProvider.Meta().(*Client)
+	cr, exists, err := client.api.GetCluster(context.Background(), clusterName)
+	if err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Fatalf("cluster %q not found", clusterName)
+	}
+	return cr.Uuid
+}
+
+// testAccCreatePksClusterOutOfBand creates a cluster without Terraform, and returns its uuid
+func testAccCreatePksClusterOutOfBand(t *testing.T, req pksapi.ClusterRequest, waitForCreate bool) string {
+	client, err := testAccFakeServer.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := client.CreateCluster(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if waitForCreate {
+		if err := client.WaitForClusterAction(ctx, req.Name, "CREATE", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cr, _, err := client.GetCluster(ctx, req.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cr.Uuid
+}
+
+func testAccGetPksClusterUuid(resourceName string, clusterUuid *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+		*clusterUuid = rs.Primary.Attributes["uuid"]
+		return nil
+	}
+}
+
 func testAccPksClusterBasicConfig(name, hostname string) string {
 	return fmt.Sprintf(`
 resource "pks_cluster" "test" {
@@ -313,3 +1047,161 @@ resource "pks_cluster" "test" {
 }
 `, name, hostname, nodes)
 }
+
+func testAccPksClusterTagsConfig(name, hostname, tags string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+
+  tags = {
+    %s
+  }
+}
+`, name, hostname, tags)
+}
+
+func testAccPksClusterNetworkProfileConfig(name, hostname, profilePrefix, lbSize string) string {
+	profileName := ""
+	if lbSize != "" {
+		profileName = "${pks_network_profile.test_" + lbSize + ".name}"
+	}
+	return fmt.Sprintf(`
+resource "pks_network_profile" "test_small" {
+  name = "%[3]s_small"
+  parameters = jsonencode({ lb_size = "small" })
+}
+
+resource "pks_network_profile" "test_medium" {
+  name = "%[3]s_medium"
+  parameters = jsonencode({ lb_size = "medium" })
+}
+
+resource "pks_cluster" "test" {
+  name = "%[1]s"
+  external_hostname = "%[2]s"
+  plan = "small"
+  network_profile_name = "%[4]s"
+}
+`, name, hostname, profilePrefix, profileName)
+}
+
+func testAccPksClusterNodePoolsConfig(name, hostname, profileName string, servicesInstances int) string {
+	return fmt.Sprintf(`
+resource "pks_compute_profile" "test" {
+  name = "%[3]s"
+
+  node_pool {
+    name = "batch"
+    instances = 1
+    node_labels = {
+      workload = "batch"
+    }
+  }
+
+  node_pool {
+    name = "services"
+    instances = 1
+  }
+}
+
+resource "pks_cluster" "test" {
+  name = "%[1]s"
+  external_hostname = "%[2]s"
+  plan = "small"
+  compute_profile_name = pks_compute_profile.test.name
+
+  node_pool {
+    name = "services"
+    instances = %[4]d
+  }
+
+  node_pool {
+    name = "batch"
+    instances = 1
+  }
+}
+`, name, hostname, profileName, servicesInstances)
+}
+
+func testAccPksClusterPartialNodePoolsConfig(name, hostname, profileName string, servicesInstances int) string {
+	return fmt.Sprintf(`
+resource "pks_compute_profile" "test" {
+  name = "%[3]s"
+
+  node_pool {
+    name = "batch"
+    instances = 1
+  }
+
+  node_pool {
+    name = "services"
+    instances = 1
+  }
+}
+
+resource "pks_cluster" "test" {
+  name = "%[1]s"
+  external_hostname = "%[2]s"
+  plan = "small"
+  compute_profile_name = pks_compute_profile.test.name
+
+  node_pool {
+    name = "services"
+    instances = %[4]d
+  }
+}
+`, name, hostname, profileName, servicesInstances)
+}
+
+func testAccPksClusterOnFailureConfig(name, hostname, onFailure string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+  on_failure = "%s"
+}
+`, name, hostname, onFailure)
+}
+
+func testAccPksClusterCreateTimeoutConfig(name, hostname, createTimeout string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+
+  timeouts {
+    create = "%s"
+  }
+}
+`, name, hostname, createTimeout)
+}
+
+func testAccPksClusterPksVersionConfig(name, hostname, pksVersion string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+  pks_version = "%s"
+}
+`, name, hostname, pksVersion)
+}
+
+func testAccPksClusterTimeoutsConfig(name, hostname string, nodes int, updateTimeout string) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+  num_nodes = %d
+
+  timeouts {
+    update = "%s"
+  }
+}
+`, name, hostname, nodes, updateTimeout)
+}
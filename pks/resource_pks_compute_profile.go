@@ -0,0 +1,323 @@
+package pks
+
+import (
+	"encoding/json"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"log"
+)
+
+func resourcePksComputeProfile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePksComputeProfileCreate,
+		Read:   resourcePksComputeProfileRead,
+		Delete: resourcePksComputeProfileDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Compute Profile Name",
+				ForceNew:    true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"az": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Availability zones that can be referenced by the control plane and node pools",
+				ForceNew:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"cpi": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "ID of the CPI config the AZ belongs to",
+							ForceNew:    true,
+						},
+
+						"cloud_properties": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "JSON document containing the IaaS-specific properties of the AZ",
+							ForceNew:         true,
+							ValidateFunc:     validateConfigJson,
+							DiffSuppressFunc: suppressEquivalentJsonDiffs,
+						},
+					},
+				},
+			},
+
+			"control_plane": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"instances": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Number of master VMs",
+							ForceNew:    true,
+						},
+
+						"instance_type": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "VM type for the masters, overriding the plan",
+							ForceNew:    true,
+						},
+
+						"az_names": {
+							Type:     schema.TypeList,
+							Optional: true,
+							ForceNew: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"node_pool": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"instances": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Number of worker VMs in the pool",
+							ForceNew:    true,
+						},
+
+						"instance_type": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "VM type for the workers in the pool, overriding the plan",
+							ForceNew:    true,
+						},
+
+						"az_names": {
+							Type:     schema.TypeList,
+							Optional: true,
+							ForceNew: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"node_labels": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							ForceNew: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"node_taints": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Taints in the form key=value:effect",
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourcePksComputeProfileCreate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Get("name").(string)
+
+	profile := pksapi.ComputeProfile{
+		Name:        name,
+		Description: d.Get("description").(string),
+		Parameters: pksapi.ComputeProfileParameters{
+			Azs: expandComputeProfileAzs(d.Get("az").([]interface{})),
+			ClusterCustomization: pksapi.ClusterCustomization{
+				ControlPlane: expandControlPlane(d.Get("control_plane").([]interface{})),
+				NodePools:    expandNodePools(d.Get("node_pool").([]interface{})),
+			},
+		},
+	}
+
+	log.Printf("[DEBUG] PKS compute profile create request configuration: %#v", profile)
+
+	err := pksClient.api.CreateComputeProfile(pksClient.stopCtx, profile)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(name)
+
+	return resourcePksComputeProfileRead(d, m)
+}
+
+func resourcePksComputeProfileRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Id()
+
+	cp, exists, err := pksClient.api.GetComputeProfile(pksClient.stopCtx, name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// resource doesn't exist, so we have to remove it from the state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", cp.Name)
+	d.Set("description", cp.Description)
+	if err := d.Set("az", flattenComputeProfileAzs(cp.Parameters.Azs)); err != nil {
+		return err
+	}
+	if err := d.Set("control_plane", flattenControlPlane(cp.Parameters.ClusterCustomization.ControlPlane)); err != nil {
+		return err
+	}
+	if err := d.Set("node_pool", flattenNodePools(cp.Parameters.ClusterCustomization.NodePools)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func resourcePksComputeProfileDelete(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+
+	return pksClient.api.DeleteComputeProfile(pksClient.stopCtx, d.Id())
+}
+
+func expandComputeProfileAzs(l []interface{}) []pksapi.ComputeProfileAz {
+	azs := make([]pksapi.ComputeProfileAz, 0, len(l))
+	for _, v := range l {
+		azMap := v.(map[string]interface{})
+		az := pksapi.ComputeProfileAz{
+			Name: azMap["name"].(string),
+			Cpi:  azMap["cpi"].(string),
+		}
+		if cloudProperties := azMap["cloud_properties"].(string); cloudProperties != "" {
+			az.CloudProperties = json.RawMessage(cloudProperties)
+		}
+		azs = append(azs, az)
+	}
+	return azs
+}
+
+func flattenComputeProfileAzs(azs []pksapi.ComputeProfileAz) []interface{} {
+	l := make([]interface{}, 0, len(azs))
+	for _, az := range azs {
+		l = append(l, map[string]interface{}{
+			"name":             az.Name,
+			"cpi":              az.Cpi,
+			"cloud_properties": string(az.CloudProperties),
+		})
+	}
+	return l
+}
+
+func expandControlPlane(l []interface{}) *pksapi.ControlPlane {
+	if len(l) == 0 || l[0] == nil {
+		return nil
+	}
+	cpMap := l[0].(map[string]interface{})
+	return &pksapi.ControlPlane{
+		Instances:    int64(cpMap["instances"].(int)),
+		InstanceType: cpMap["instance_type"].(string),
+		AzNames:      expandStringList(cpMap["az_names"].([]interface{})),
+	}
+}
+
+func flattenControlPlane(cp *pksapi.ControlPlane) []interface{} {
+	if cp == nil {
+		return []interface{}{}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"instances":     cp.Instances,
+			"instance_type": cp.InstanceType,
+			"az_names":      cp.AzNames,
+		},
+	}
+}
+
+// expandNodePools is shared by compute profiles and the node_pool overrides of clusters,
+// which don't have an instance_type or az_names
+func expandNodePools(l []interface{}) []pksapi.NodePool {
+	pools := make([]pksapi.NodePool, 0, len(l))
+	for _, v := range l {
+		poolMap := v.(map[string]interface{})
+		instanceType, _ := poolMap["instance_type"].(string)
+		azNames, _ := poolMap["az_names"].([]interface{})
+		pool := pksapi.NodePool{
+			Name:         poolMap["name"].(string),
+			Instances:    int64(poolMap["instances"].(int)),
+			InstanceType: instanceType,
+			AzNames:      expandStringList(azNames),
+			NodeTaints:   expandStringList(poolMap["node_taints"].([]interface{})),
+		}
+		if labels := poolMap["node_labels"].(map[string]interface{}); len(labels) > 0 {
+			pool.NodeLabels = expandStringMap(labels)
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+func flattenNodePools(pools []pksapi.NodePool) []interface{} {
+	l := make([]interface{}, 0, len(pools))
+	for _, pool := range pools {
+		l = append(l, map[string]interface{}{
+			"name":          pool.Name,
+			"instances":     pool.Instances,
+			"instance_type": pool.InstanceType,
+			"az_names":      pool.AzNames,
+			"node_labels":   pool.NodeLabels,
+			"node_taints":   pool.NodeTaints,
+		})
+	}
+	return l
+}
+
+func expandStringList(l []interface{}) []string {
+	if len(l) == 0 {
+		return nil
+	}
+	s := make([]string, 0, len(l))
+	for _, v := range l {
+		s = append(s, v.(string))
+	}
+	return s
+}
+
+func expandStringMap(m map[string]interface{}) map[string]string {
+	s := make(map[string]string, len(m))
+	for k, v := range m {
+		s[k] = v.(string)
+	}
+	return s
+}
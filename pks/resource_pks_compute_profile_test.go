@@ -0,0 +1,108 @@
+package pks
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"testing"
+)
+
+func TestAccPksComputeProfile_basic(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_compute_profile.test"
+	profileName := "tf_acc_cp_basic_" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksComputeProfileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksComputeProfileBasicConfig(profileName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksComputeProfileExists(resourceName, profileName),
+					resource.TestCheckResourceAttr(resourceName, "name", profileName),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.name", "workers"),
+					resource.TestCheckResourceAttr(resourceName, "node_pool.0.instances", "2"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckPksComputeProfileExists(resourceName, profileName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No Compute Profile Name is set as ID")
+		}
+
+		client := testAccProvider.Meta().(*Client)
+		cp, exists, err := client.api.GetComputeProfile(context.Background(), profileName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("Compute profile %q not found", profileName)
+		}
+
+		if cp.Name != profileName {
+			return fmt.Errorf("Actual compute profile name %q doesn't match expected %q", cp.Name, profileName)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckPksComputeProfileDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "pks_compute_profile" {
+			continue
+		}
+
+		_, exists, err := client.api.GetComputeProfile(context.Background(), rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("Error checking compute profile %q is destroyed: %q", rs.Primary.ID, err.Error())
+		}
+		if exists {
+			return fmt.Errorf("compute profile %q still exists after destruction", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccPksComputeProfileBasicConfig(name string) string {
+	return fmt.Sprintf(`
+resource "pks_compute_profile" "test" {
+  name = "%s"
+  description = "acceptance test profile"
+
+  control_plane {
+    instances = 1
+  }
+
+  node_pool {
+    name = "workers"
+    instances = 2
+    node_labels = {
+      tier = "batch"
+    }
+  }
+}
+`, name)
+}
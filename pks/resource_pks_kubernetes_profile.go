@@ -0,0 +1,153 @@
+package pks
+
+import (
+	"encoding/json"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"log"
+)
+
+func resourcePksKubernetesProfile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePksKubernetesProfileCreate,
+		Read:   resourcePksKubernetesProfileRead,
+		Delete: resourcePksKubernetesProfileDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Kubernetes Profile Name",
+				ForceNew:    true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"customization": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"component": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "Kubernetes component to customize, one of kube-apiserver, kube-controller-manager, kubelet",
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice([]string{"kube-apiserver", "kube-controller-manager", "kubelet"}, false),
+						},
+
+						"arguments": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "JSON object of command line flags to set on the component",
+							ForceNew:         true,
+							ValidateFunc:     validateConfigJson,
+							DiffSuppressFunc: suppressEquivalentJsonDiffs,
+						},
+
+						"file_arguments": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "JSON object of command line flags whose values are file contents, such as an OIDC CA",
+							ForceNew:         true,
+							ValidateFunc:     validateConfigJson,
+							DiffSuppressFunc: suppressEquivalentJsonDiffs,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourcePksKubernetesProfileCreate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Get("name").(string)
+
+	profile := pksapi.KubernetesProfile{
+		Name:           name,
+		Description:    d.Get("description").(string),
+		Customizations: expandKubernetesProfileCustomizations(d.Get("customization").([]interface{})),
+	}
+
+	log.Printf("[DEBUG] PKS kubernetes profile create request configuration: %#v", profile)
+
+	err := pksClient.api.CreateKubernetesProfile(pksClient.stopCtx, profile)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(name)
+
+	return resourcePksKubernetesProfileRead(d, m)
+}
+
+func resourcePksKubernetesProfileRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Id()
+
+	kp, exists, err := pksClient.api.GetKubernetesProfile(pksClient.stopCtx, name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// resource doesn't exist, so we have to remove it from the state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", kp.Name)
+	d.Set("description", kp.Description)
+	if err := d.Set("customization", flattenKubernetesProfileCustomizations(kp.Customizations)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func resourcePksKubernetesProfileDelete(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+
+	return pksClient.api.DeleteKubernetesProfile(pksClient.stopCtx, d.Id())
+}
+
+func expandKubernetesProfileCustomizations(l []interface{}) []pksapi.KubernetesProfileCustomization {
+	customizations := make([]pksapi.KubernetesProfileCustomization, 0, len(l))
+	for _, v := range l {
+		cMap := v.(map[string]interface{})
+		c := pksapi.KubernetesProfileCustomization{
+			Component: cMap["component"].(string),
+		}
+		if arguments := cMap["arguments"].(string); arguments != "" {
+			c.Arguments = json.RawMessage(arguments)
+		}
+		if fileArguments := cMap["file_arguments"].(string); fileArguments != "" {
+			c.FileArguments = json.RawMessage(fileArguments)
+		}
+		customizations = append(customizations, c)
+	}
+	return customizations
+}
+
+func flattenKubernetesProfileCustomizations(customizations []pksapi.KubernetesProfileCustomization) []interface{} {
+	l := make([]interface{}, 0, len(customizations))
+	for _, c := range customizations {
+		l = append(l, map[string]interface{}{
+			"component":      c.Component,
+			"arguments":      string(c.Arguments),
+			"file_arguments": string(c.FileArguments),
+		})
+	}
+	return l
+}
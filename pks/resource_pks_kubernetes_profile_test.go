@@ -0,0 +1,105 @@
+package pks
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"testing"
+)
+
+func TestAccPksKubernetesProfile_basic(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_kubernetes_profile.test"
+	profileName := "tf_acc_kp_basic_" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksKubernetesProfileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksKubernetesProfileBasicConfig(profileName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksKubernetesProfileExists(resourceName, profileName),
+					resource.TestCheckResourceAttr(resourceName, "name", profileName),
+					resource.TestCheckResourceAttr(resourceName, "customization.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "customization.0.component", "kube-apiserver"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckPksKubernetesProfileExists(resourceName, profileName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No Kubernetes Profile Name is set as ID")
+		}
+
+		client := testAccProvider.Meta().(*Client)
+		kp, exists, err := client.api.GetKubernetesProfile(context.Background(), profileName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("Kubernetes profile %q not found", profileName)
+		}
+
+		if kp.Name != profileName {
+			return fmt.Errorf("Actual kubernetes profile name %q doesn't match expected %q", kp.Name, profileName)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckPksKubernetesProfileDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "pks_kubernetes_profile" {
+			continue
+		}
+
+		_, exists, err := client.api.GetKubernetesProfile(context.Background(), rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("Error checking kubernetes profile %q is destroyed: %q", rs.Primary.ID, err.Error())
+		}
+		if exists {
+			return fmt.Errorf("kubernetes profile %q still exists after destruction", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccPksKubernetesProfileBasicConfig(name string) string {
+	return fmt.Sprintf(`
+resource "pks_kubernetes_profile" "test" {
+  name = "%s"
+  description = "acceptance test profile"
+
+  customization {
+    component = "kube-apiserver"
+    arguments = <<JSON
+{
+  "oidc-username-claim": "email",
+  "oidc-groups-claim": "groups"
+}
+JSON
+  }
+}
+`, name)
+}
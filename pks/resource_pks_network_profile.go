@@ -3,10 +3,97 @@ package pks
 import (
 	"bytes"
 	"encoding/json"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"log"
 	"reflect"
 )
 
+func resourcePksNetworkProfile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePksNetworkProfileCreate,
+		Read:   resourcePksNetworkProfileRead,
+		Delete: resourcePksNetworkProfileDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Network Profile Name",
+				ForceNew:    true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"parameters": {
+				Type:             schema.TypeString,
+				Required:         true,
+				Description:      "JSON document containing the NSX-T settings for the profile, as accepted by the PKS API",
+				ForceNew:         true,
+				ValidateFunc:     validateConfigJson,
+				DiffSuppressFunc: suppressEquivalentJsonDiffs,
+			},
+		},
+	}
+}
+
+func resourcePksNetworkProfileCreate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Get("name").(string)
+
+	profile := pksapi.NetworkProfile{
+		Name:        name,
+		Description: d.Get("description").(string),
+		Parameters:  json.RawMessage(d.Get("parameters").(string)),
+	}
+
+	log.Printf("[DEBUG] PKS network profile create request configuration: %#v", profile)
+
+	err := pksClient.api.CreateNetworkProfile(pksClient.stopCtx, profile)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(name)
+
+	return resourcePksNetworkProfileRead(d, m)
+}
+
+func resourcePksNetworkProfileRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	name := d.Id()
+
+	np, exists, err := pksClient.api.GetNetworkProfile(pksClient.stopCtx, name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// resource doesn't exist, so we have to remove it from the state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", np.Name)
+	d.Set("description", np.Description)
+	d.Set("parameters", string(np.Parameters))
+
+	return nil
+}
+
+func resourcePksNetworkProfileDelete(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+
+	return pksClient.api.DeleteNetworkProfile(pksClient.stopCtx, d.Id())
+}
+
 func validateConfigJson(configI interface{}, k string) ([]string, []error) {
 	dataJSON := configI.(string)
 	dataMap := map[string]interface{}{}
@@ -0,0 +1,99 @@
+package pks
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"testing"
+)
+
+func TestAccPksNetworkProfile_basic(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_network_profile.test"
+	profileName := "tf_acc_np_basic_" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksNetworkProfileDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksNetworkProfileBasicConfig(profileName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksNetworkProfileExists(resourceName, profileName),
+					resource.TestCheckResourceAttr(resourceName, "name", profileName),
+					resource.TestCheckResourceAttr(resourceName, "description", "acceptance test profile"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckPksNetworkProfileExists(resourceName, profileName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No Network Profile Name is set as ID")
+		}
+
+		client := testAccProvider.Meta().(*Client)
+		np, exists, err := client.api.GetNetworkProfile(context.Background(), profileName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("Network profile %q not found", profileName)
+		}
+
+		if np.Name != profileName {
+			return fmt.Errorf("Actual network profile name %q doesn't match expected %q", np.Name, profileName)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckPksNetworkProfileDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "pks_network_profile" {
+			continue
+		}
+
+		_, exists, err := client.api.GetNetworkProfile(context.Background(), rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("Error checking network profile %q is destroyed: %q", rs.Primary.ID, err.Error())
+		}
+		if exists {
+			return fmt.Errorf("network profile %q still exists after destruction", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccPksNetworkProfileBasicConfig(name string) string {
+	return fmt.Sprintf(`
+resource "pks_network_profile" "test" {
+  name = "%s"
+  description = "acceptance test profile"
+  parameters = <<JSON
+{
+  "lb_size": "small"
+}
+JSON
+}
+`, name)
+}
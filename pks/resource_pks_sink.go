@@ -1 +1,175 @@
 package pks
+
+import (
+	"fmt"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"log"
+	"strings"
+)
+
+func resourcePksSink() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePksSinkCreate,
+		Read:   resourcePksSinkRead,
+		Update: resourcePksSinkUpdate,
+		Delete: resourcePksSinkDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"cluster_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the cluster the sink collects logs or metrics from",
+				ForceNew:    true,
+			},
+
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Sink Name",
+				ForceNew:    true,
+			},
+
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Kind of data forwarded by the sink, one of syslog, metric",
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"syslog", "metric"}, false),
+			},
+
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Hostname or IP of the destination for the sink",
+			},
+
+			"port": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				Description:  "Port of the destination for the sink",
+				ValidateFunc: validation.IntBetween(1, 65535),
+			},
+
+			"enable_tls": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Use TLS when sending data to the destination",
+			},
+
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip verification of the destination's TLS certificate",
+			},
+		},
+	}
+}
+
+// sink names are only unique within a cluster, so the ID is made up of both
+func pksSinkId(clusterName, sinkName string) string {
+	return clusterName + "/" + sinkName
+}
+
+func parsePksSinkId(id string) (string, string, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected format for sink ID %q, expected cluster_name/sink_name", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func pksSinkFromResourceData(d *schema.ResourceData) pksapi.Sink {
+	return pksapi.Sink{
+		Name:               d.Get("name").(string),
+		Type:               d.Get("type").(string),
+		Host:               d.Get("host").(string),
+		Port:               int64(d.Get("port").(int)),
+		EnableTls:          d.Get("enable_tls").(bool),
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+	}
+}
+
+func resourcePksSinkCreate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	clusterName := d.Get("cluster_name").(string)
+	sink := pksSinkFromResourceData(d)
+
+	log.Printf("[DEBUG] PKS sink create request configuration: %#v", sink)
+
+	err := pksClient.api.CreateSink(pksClient.stopCtx, clusterName, sink)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(pksSinkId(clusterName, sink.Name))
+
+	return resourcePksSinkRead(d, m)
+}
+
+func resourcePksSinkRead(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	// on import only ID is set, so both names are taken from there
+	clusterName, sinkName, err := parsePksSinkId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	sink, exists, err := pksClient.api.GetSink(pksClient.stopCtx, clusterName, sinkName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// resource doesn't exist, so we have to remove it from the state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("cluster_name", clusterName)
+	d.Set("name", sink.Name)
+	d.Set("type", sink.Type)
+	d.Set("host", sink.Host)
+	d.Set("port", sink.Port)
+	d.Set("enable_tls", sink.EnableTls)
+	d.Set("insecure_skip_verify", sink.InsecureSkipVerify)
+
+	return nil
+}
+
+func resourcePksSinkUpdate(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	clusterName, sinkName, err := parsePksSinkId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("host") || d.HasChange("port") || d.HasChange("enable_tls") || d.HasChange("insecure_skip_verify") {
+		sink := pksSinkFromResourceData(d)
+
+		log.Printf("[DEBUG] PKS sink update request configuration: %#v", sink)
+
+		err = pksClient.api.UpdateSink(pksClient.stopCtx, clusterName, sinkName, sink)
+		if err != nil {
+			return err
+		}
+	}
+
+	return resourcePksSinkRead(d, m)
+}
+
+func resourcePksSinkDelete(d *schema.ResourceData, m interface{}) error {
+	pksClient := m.(*Client)
+	clusterName, sinkName, err := parsePksSinkId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	return pksClient.api.DeleteSink(pksClient.stopCtx, clusterName, sinkName)
+}
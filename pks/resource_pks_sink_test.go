@@ -0,0 +1,119 @@
+package pks
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"testing"
+)
+
+func TestAccPksSink_update(t *testing.T) {
+	rString := acctest.RandString(6)
+	resourceName := "pks_sink.test"
+	clusterName := "tf_acc_sink_" + rString
+	hostname := clusterName + ".example.com"
+	sinkName := "tf-acc-sink-" + rString
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPksSinkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPksSinkConfig(clusterName, hostname, sinkName, 514),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksSinkExists(resourceName, clusterName, sinkName),
+					resource.TestCheckResourceAttr(resourceName, "cluster_name", clusterName),
+					resource.TestCheckResourceAttr(resourceName, "type", "syslog"),
+					resource.TestCheckResourceAttr(resourceName, "port", "514"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccPksSinkConfig(clusterName, hostname, sinkName, 6514),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPksSinkExists(resourceName, clusterName, sinkName),
+					resource.TestCheckResourceAttr(resourceName, "port", "6514"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckPksSinkExists(resourceName, clusterName, sinkName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if rs.Primary.ID != pksSinkId(clusterName, sinkName) {
+			return fmt.Errorf("Unexpected sink ID %q", rs.Primary.ID)
+		}
+
+		client := testAccProvider.Meta().(*Client)
+		sink, exists, err := client.api.GetSink(context.Background(), clusterName, sinkName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("Sink %q not found on cluster %q", sinkName, clusterName)
+		}
+
+		if sink.Name != sinkName {
+			return fmt.Errorf("Actual sink name %q doesn't match expected %q", sink.Name, sinkName)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckPksSinkDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "pks_sink" {
+			continue
+		}
+
+		clusterName, sinkName, err := parsePksSinkId(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		_, exists, err := client.api.GetSink(context.Background(), clusterName, sinkName)
+		if err != nil {
+			return fmt.Errorf("Error checking sink %q is destroyed: %q", rs.Primary.ID, err.Error())
+		}
+		if exists {
+			return fmt.Errorf("sink %q still exists after destruction", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccPksSinkConfig(clusterName, hostname, sinkName string, port int) string {
+	return fmt.Sprintf(`
+resource "pks_cluster" "test" {
+  name = "%s"
+  external_hostname = "%s"
+  plan = "small"
+}
+
+resource "pks_sink" "test" {
+  cluster_name = pks_cluster.test.name
+  name = "%s"
+  type = "syslog"
+  host = "logs.example.com"
+  port = %d
+  enable_tls = true
+}
+`, clusterName, hostname, sinkName, port)
+}
@@ -0,0 +1,395 @@
+package pksapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cleanhttp"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokens are refreshed this long before they expire, so they don't expire while a request is in flight
+const tokenExpiryMargin = 2 * time.Minute
+
+// Client talks to the PKS API, logging in to UAA and retrying transient failures as needed.
+// It is safe for concurrent use.
+type Client struct {
+	apiUrl, uaaUrl, clientId, clientSecret, username, password string
+	httpClient                                                 *http.Client
+	maxRetries                                                 int64
+	retryMinWait, retryMaxWait, pollInterval                   time.Duration
+	clock                                                      Clock
+
+	// the token may be refreshed by concurrent requests, so is guarded by tokenLock
+	tokenLock   sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithBaseURL sets the base URL of the PKS API, e.g. https://pks.example.com:9021
+func WithBaseURL(apiUrl string) Option {
+	return func(client *Client) {
+		client.apiUrl = strings.TrimSuffix(apiUrl, "/")
+	}
+}
+
+// WithUAAURL sets the base URL of the UAA that issues tokens for the PKS API, e.g. https://pks.example.com:8443
+func WithUAAURL(uaaUrl string) Option {
+	return func(client *Client) {
+		client.uaaUrl = strings.TrimSuffix(uaaUrl, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for all requests, e.g. to configure TLS or logging
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithToken uses an existing UAA token. It can't be refreshed, so requests fail once it expires.
+func WithToken(token string) Option {
+	return func(client *Client) {
+		client.token = token
+	}
+}
+
+// WithClientCredentials logs in with the client credentials grant
+func WithClientCredentials(clientId, clientSecret string) Option {
+	return func(client *Client) {
+		client.clientId = clientId
+		client.clientSecret = clientSecret
+	}
+}
+
+// WithUserCredentials logs in as a UAA user, the same as pks login
+func WithUserCredentials(username, password string) Option {
+	return func(client *Client) {
+		client.username = username
+		client.password = password
+	}
+}
+
+// WithRetries sets how many times transient failures are retried, and the bounds of the backoff between retries
+func WithRetries(maxRetries int64, minWait, maxWait time.Duration) Option {
+	return func(client *Client) {
+		client.maxRetries = maxRetries
+		client.retryMinWait = minWait
+		client.retryMaxWait = maxWait
+	}
+}
+
+// WithPollInterval sets how often WaitForClusterAction checks on the cluster
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(client *Client) {
+		client.pollInterval = pollInterval
+	}
+}
+
+// WithClock sets the clock used for waiting between polls and retries, and for checking token expiry
+func WithClock(clock Clock) Option {
+	return func(client *Client) {
+		client.clock = clock
+	}
+}
+
+// NewClient creates a Client for the PKS API. WithBaseURL is required, as is WithUAAURL unless WithToken is used.
+func NewClient(opts ...Option) (*Client, error) {
+	client := &Client{
+		httpClient:   cleanhttp.DefaultClient(),
+		maxRetries:   5,
+		retryMinWait: time.Second,
+		retryMaxWait: 30 * time.Second,
+		pollInterval: 10 * time.Second,
+		clock:        SystemClock,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if client.apiUrl == "" {
+		return nil, fmt.Errorf("the PKS API URL must be set")
+	}
+	if client.canLogin() && client.uaaUrl == "" {
+		return nil, fmt.Errorf("the UAA URL must be set to login to PKS")
+	}
+	if !client.canLogin() && client.token == "" {
+		return nil, fmt.Errorf("a token, client credentials or user credentials must be set to authenticate to PKS")
+	}
+	if client.pollInterval <= 0 {
+		return nil, fmt.Errorf("the poll interval must be positive")
+	}
+	return client, nil
+}
+
+// Login gets a token straight away, so bad credentials can be reported before any other request is made.
+// Otherwise the client logs in on first use, and again as the token expires.
+func (client *Client) Login(ctx context.Context) error {
+	_, err := client.accessToken(ctx)
+	return err
+}
+
+func ClientLogin(ctx context.Context, httpClient *http.Client, uaaUrl, clientId, clientSecret string) (*Token, error) {
+	/*
+		Replicating this working curl command, where the UAA URL defaults to https://${PKS_ADDRESS}:8443:
+		curl -s ${UAA_URL}/oauth/token
+		     -k -X POST -H 'Accept: application/json;charset=utf-8'
+		     -u "client_id:client_secret" -H 'Content-Type: application/x-www-form-urlencoded;charset=utf-8'
+		     -d 'grant_type=client_credentials'
+	*/
+	tokenReqData := url.Values{"grant_type": {"client_credentials"}}
+	return requestToken(ctx, httpClient, uaaUrl, clientId, clientSecret, tokenReqData)
+}
+
+func UserLogin(ctx context.Context, httpClient *http.Client, uaaUrl, username, password string) (*Token, error) {
+	/*
+		Same as the pks cli login, which uses the password grant with the public pks_cli client:
+		curl -s ${UAA_URL}/oauth/token
+		     -k -X POST -H 'Accept: application/json;charset=utf-8'
+		     -u "pks_cli:" -H 'Content-Type: application/x-www-form-urlencoded;charset=utf-8'
+		     -d 'grant_type=password&username=${USERNAME}&password=${PASSWORD}'
+	*/
+	tokenReqData := url.Values{
+		"grant_type": {"password"},
+		"username":   {username},
+		"password":   {password},
+	}
+	return requestToken(ctx, httpClient, uaaUrl, "pks_cli", "", tokenReqData)
+}
+
+func requestToken(ctx context.Context, httpClient *http.Client, uaaUrl, clientId, clientSecret string, tokenReqData url.Values) (*Token, error) {
+	req, _ := http.NewRequestWithContext(ctx, "POST", uaaUrl+"/oauth/token", strings.NewReader(tokenReqData.Encode()))
+	req.SetBasicAuth(clientId, clientSecret)
+	req.Header["Content-Type"] = []string{"application/x-www-form-urlencoded;charset=utf-8"}
+	req.Header["Accept"] = []string{"application/json; charset=utf-8"}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// this doesn't catch 4xx/5xx !
+		return nil, fmt.Errorf("error connecting to PKS API to get token %q: %w", req.URL.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("PKS token request failed: %w", newAPIError(resp))
+	}
+
+	var token Token
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token response from PKS API %q: %q", req.URL.String(), err.Error())
+	}
+
+	return &token, nil
+}
+
+// accessToken returns the token to use for API requests, logging in again first if it is close to expiring
+func (client *Client) accessToken(ctx context.Context) (string, error) {
+	client.tokenLock.Lock()
+	defer client.tokenLock.Unlock()
+
+	expiring := !client.tokenExpiry.IsZero() && client.clock.Now().Add(tokenExpiryMargin).After(client.tokenExpiry)
+	if client.canLogin() && (client.token == "" || expiring) {
+		if err := client.login(ctx); err != nil {
+			return "", err
+		}
+	}
+	return client.token, nil
+}
+
+// refreshToken logs in again after staleToken was rejected, unless a concurrent request has already done so
+func (client *Client) refreshToken(ctx context.Context, staleToken string) (string, error) {
+	client.tokenLock.Lock()
+	defer client.tokenLock.Unlock()
+
+	if client.token == staleToken {
+		if err := client.login(ctx); err != nil {
+			return "", err
+		}
+	}
+	return client.token, nil
+}
+
+// canLogin is false when the provider was given a token, as then there are no credentials to get a new one with
+func (client *Client) canLogin() bool {
+	return (client.clientId != "" && client.clientSecret != "") || (client.username != "" && client.password != "")
+}
+
+// login must be called with tokenLock held
+func (client *Client) login(ctx context.Context) error {
+	var token *Token
+	var err error
+	if client.clientId != "" && client.clientSecret != "" {
+		token, err = ClientLogin(ctx, client.httpClient, client.uaaUrl, client.clientId, client.clientSecret)
+	} else {
+		token, err = UserLogin(ctx, client.httpClient, client.uaaUrl, client.username, client.password)
+	}
+	if err != nil {
+		return err
+	}
+
+	client.token = token.AccessToken
+	client.tokenExpiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		client.tokenExpiry = client.clock.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
+
+// doRequest sends an authenticated request to the PKS API, encoding body as JSON if it is set.
+// If the token is rejected and we have credentials, it logs in again and retries the request once.
+func (client *Client) doRequest(ctx context.Context, method, reqUrl string, body interface{}) (*http.Response, error) {
+	var b []byte
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := client.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.sendRequestWithRetries(ctx, method, reqUrl, b, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !client.canLogin() {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	token, err = client.refreshToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return client.sendRequestWithRetries(ctx, method, reqUrl, b, token)
+}
+
+// sendRequestWithRetries retries transient failures with exponential backoff, up to the client's maxRetries.
+// Mutating requests are only retried when PKS tells us it didn't process them, so they are never applied twice.
+func (client *Client) sendRequestWithRetries(ctx context.Context, method, reqUrl string, body []byte, token string) (*http.Response, error) {
+	for attempt := int64(0); ; attempt++ {
+		resp, err := client.sendRequest(ctx, method, reqUrl, body, token)
+		if attempt >= client.maxRetries {
+			return resp, err
+		}
+
+		var wait time.Duration
+		if err != nil {
+			if !isIdempotent(method) || isCertificateError(err) || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("[DEBUG] %s %s failed: %s", method, reqUrl, err)
+			wait = client.retryBackoff(attempt)
+		} else if isRetryableStatus(method, resp.StatusCode) {
+			log.Printf("[DEBUG] %s %s returned status %q", method, reqUrl, resp.Status)
+			wait = client.retryAfter(resp)
+			if wait == 0 {
+				wait = client.retryBackoff(attempt)
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			return resp, nil
+		}
+
+		log.Printf("[DEBUG] Retrying %s %s in %s (retry %d of %d)", method, reqUrl, wait, attempt+1, client.maxRetries)
+		if err := client.clock.Sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// certificate errors won't go away by themselves, so aren't worth retrying
+func isCertificateError(err error) bool {
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		// the request was turned away before being processed
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+// retryBackoff doubles the wait for each retry, adding jitter so concurrent requests don't retry in lockstep
+func (client *Client) retryBackoff(attempt int64) time.Duration {
+	wait := client.retryMinWait << uint(attempt)
+	if wait <= 0 || wait > client.retryMaxWait {
+		wait = client.retryMaxWait
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// retryAfter returns the wait requested by a 429 or 503 response, or zero if there isn't one
+func (client *Client) retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = date.Sub(client.clock.Now())
+	}
+
+	if wait < 0 {
+		return 0
+	} else if wait > client.retryMaxWait {
+		return client.retryMaxWait
+	}
+	return wait
+}
+
+func (client *Client) sendRequest(ctx context.Context, method, reqUrl string, body []byte, token string) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header["Authorization"] = []string{"Bearer " + token}
+	if body != nil {
+		req.Header["Content-Type"] = []string{"application/json; charset=utf-8"}
+	}
+	req.Header["Accept"] = []string{"application/json; charset=utf-8"}
+	return client.httpClient.Do(req)
+}
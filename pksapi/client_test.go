@@ -0,0 +1,262 @@
+package pksapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer is a UAA and PKS API in one, where the API only accepts the last token issued
+type tokenServer struct {
+	*httptest.Server
+	expiresIn int64
+
+	mu       sync.Mutex
+	logins   int
+	apiCalls int
+	token    string
+	// revokeOnLogin rejects every token as soon as it's issued
+	revokeOnLogin bool
+}
+
+func newTokenServer(expiresIn int64) *tokenServer {
+	s := &tokenServer{expiresIn: expiresIn}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.logins++
+		token := fmt.Sprintf("token-%d", s.logins)
+		s.token = token
+		if s.revokeOnLogin {
+			s.token = "revoked"
+		}
+		json.NewEncoder(w).Encode(Token{AccessToken: token, ExpiresIn: s.expiresIn})
+	})
+	mux.HandleFunc("/v1/plans", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.apiCalls++
+		if s.token == "" || r.Header.Get("Authorization") != "Bearer "+s.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// revoke rejects the current token, as if it had been revoked or UAA had restarted.
+// If always is set, tokens issued afterwards are rejected too
+func (s *tokenServer) revoke(always bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = "revoked"
+	s.revokeOnLogin = always
+}
+
+func (s *tokenServer) counts() (logins, apiCalls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins, s.apiCalls
+}
+
+func newTokenClient(t *testing.T, s *tokenServer, clock Clock, opts ...Option) *Client {
+	opts = append([]Option{
+		WithBaseURL(s.URL),
+		WithUAAURL(s.URL),
+		WithClock(clock),
+		WithRetries(0, time.Millisecond, time.Millisecond),
+	}, opts...)
+	client, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return client
+}
+
+func TestAccessToken_refreshedBeforeExpiry(t *testing.T) {
+	server := newTokenServer(600)
+	defer server.Close()
+	clock := &fakeClock{now: time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)}
+	client := newTokenClient(t, server, clock, WithClientCredentials("id", "secret"))
+	ctx := context.Background()
+
+	steps := []struct {
+		advance        time.Duration
+		expectedLogins int
+	}{
+		{advance: 0, expectedLogins: 1},
+		// well within the token's 10 minutes
+		{advance: 5 * time.Minute, expectedLogins: 1},
+		// inside the margin before expiry, so the token is refreshed before it's used
+		{advance: 3*time.Minute + time.Second, expectedLogins: 2},
+		{advance: time.Minute, expectedLogins: 2},
+	}
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		if _, err := client.GetPlans(ctx); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if logins, _ := server.counts(); logins != step.expectedLogins {
+			t.Errorf("step %d: expected %d logins, got %d", i, step.expectedLogins, logins)
+		}
+	}
+}
+
+func TestDoRequest_rejectedToken(t *testing.T) {
+	testCases := map[string]struct {
+		opts []Option
+		// alwaysReject revokes every token, including the one from logging in again
+		alwaysReject     bool
+		expectError      bool
+		expectedLogins   int
+		expectedAPICalls int
+	}{
+		"client credentials log in again and retry": {
+			opts:             []Option{WithClientCredentials("id", "secret")},
+			expectedLogins:   2,
+			expectedAPICalls: 3,
+		},
+		"user credentials log in again and retry": {
+			opts:             []Option{WithUserCredentials("user", "password")},
+			expectedLogins:   2,
+			expectedAPICalls: 3,
+		},
+		"retried only once": {
+			opts:             []Option{WithClientCredentials("id", "secret")},
+			alwaysReject:     true,
+			expectError:      true,
+			expectedLogins:   2,
+			expectedAPICalls: 3,
+		},
+		"token only isn't retried": {
+			opts:             []Option{WithToken("token-0")},
+			expectError:      true,
+			expectedLogins:   0,
+			expectedAPICalls: 2,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := newTokenServer(600)
+			defer server.Close()
+			client := newTokenClient(t, server, SystemClock, tc.opts...)
+			ctx := context.Background()
+
+			// the first request works for clients that can log in, then the token is rejected
+			if _, err := client.GetPlans(ctx); err != nil && client.canLogin() {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			server.revoke(tc.alwaysReject)
+
+			_, err := client.GetPlans(ctx)
+			if tc.expectError && err == nil {
+				t.Fatal("expected an error")
+			} else if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectError && !IsUnauthorized(err) {
+				t.Errorf("expected an unauthorized error, got %v", err)
+			}
+
+			logins, apiCalls := server.counts()
+			if logins != tc.expectedLogins {
+				t.Errorf("expected %d logins, got %d", tc.expectedLogins, logins)
+			}
+			if apiCalls != tc.expectedAPICalls {
+				t.Errorf("expected %d API calls, got %d", tc.expectedAPICalls, apiCalls)
+			}
+		})
+	}
+}
+
+func TestAccessToken_concurrentRefresh(t *testing.T) {
+	testCases := map[string]func(s *tokenServer, clock *fakeClock){
+		"expired": func(s *tokenServer, clock *fakeClock) {
+			clock.now = clock.now.Add(time.Hour)
+		},
+		"rejected": func(s *tokenServer, clock *fakeClock) {
+			s.revoke(false)
+		},
+	}
+
+	for name, invalidate := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := newTokenServer(600)
+			defer server.Close()
+			clock := &fakeClock{now: time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)}
+			client := newTokenClient(t, server, clock, WithClientCredentials("id", "secret"))
+			ctx := context.Background()
+
+			if err := client.Login(ctx); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			invalidate(server, clock)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 20)
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := client.GetPlans(ctx)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			if logins, _ := server.counts(); logins != 2 {
+				t.Errorf("expected the concurrent requests to share a single login, got %d logins in total", logins)
+			}
+		})
+	}
+}
+
+func TestDoRequest_loginRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "unauthorized", "error_description": "Bad credentials"}`))
+	}))
+	defer server.Close()
+	client := newTokenClient(t, &tokenServer{Server: server}, SystemClock, WithClientCredentials("id", "wrong"))
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"GetCluster": func() error {
+			_, _, err := client.GetCluster(ctx, "my-cluster")
+			return err
+		},
+		"CreateCluster": func() error {
+			return client.CreateCluster(ctx, ClusterRequest{Name: "my-cluster"})
+		},
+		"DeleteCluster": func() error {
+			return client.DeleteCluster(ctx, "my-cluster")
+		},
+		"GetPlans": func() error {
+			_, err := client.GetPlans(ctx)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if err := call(); !IsUnauthorized(err) {
+				t.Errorf("expected the UAA rejection to be an unauthorized error, got %v", err)
+			}
+		})
+	}
+}
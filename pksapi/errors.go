@@ -0,0 +1,103 @@
+package pksapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// APIError is returned by the SDK when PKS or UAA responds with an unexpected status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+	// Message is the error reported by PKS in the response, if it could be decoded
+	Message string
+	Body    []byte
+}
+
+// pksErrorResponse covers the error formats of both the PKS API and UAA
+type pksErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Description      string `json:"description"`
+	Message          string `json:"message"`
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := ioutil.ReadAll(resp.Body)
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.URL = resp.Request.URL.String()
+	}
+
+	var errResp pksErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		// prefer the most descriptive field that is set
+		for _, msg := range []string{errResp.Description, errResp.ErrorDescription, errResp.Message, errResp.Error} {
+			if msg != "" {
+				apiErr.Message = msg
+				break
+			}
+		}
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s returned status %q: %s", e.Method, e.URL, e.Status, e.Message)
+	}
+	return fmt.Sprintf("%s %s returned status %q with response: %q", e.Method, e.URL, e.Status, e.Body)
+}
+
+// ActionFailedError is returned when PKS reports that the cluster action being waited on failed
+type ActionFailedError struct {
+	ClusterName string
+	Action      string
+	Description string
+}
+
+func (e *ActionFailedError) Error() string {
+	return fmt.Sprintf("Action %s failed on cluster %q with error: %q", e.Action, e.ClusterName, e.Description)
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+func IsUnprocessable(err error) bool {
+	return hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+// IsActionFailed reports whether waiting on a cluster action ended because the action failed,
+// rather than because the wait timed out, was stopped or couldn't reach PKS
+func IsActionFailed(err error) bool {
+	var actionErr *ActionFailedError
+	return errors.As(err, &actionErr)
+}
@@ -0,0 +1,418 @@
+// Package pksapi is a client for the Pivotal Container Service (PKS) API
+package pksapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ClusterAPI manages PKS clusters, whose changes are applied asynchronously
+type ClusterAPI interface {
+	// GetCluster returns false if the cluster doesn't exist
+	GetCluster(ctx context.Context, clusterName string) (*ClusterResponse, bool, error)
+	GetClusterCredentials(ctx context.Context, clusterName string) (*KubeConfig, error)
+	CreateCluster(ctx context.Context, clusterReq ClusterRequest) error
+	UpdateCluster(ctx context.Context, clusterName string, updateClusterReq UpdateClusterParameters) error
+	UpgradeCluster(ctx context.Context, clusterName string) error
+	// RotateClusterCertificates starts an UPDATE action on the cluster that replaces its certificates
+	RotateClusterCertificates(ctx context.Context, clusterName string) error
+	DeleteCluster(ctx context.Context, clusterName string) error
+	// WaitForClusterAction waits for the cluster's last action to finish, returning an error if it failed
+	WaitForClusterAction(ctx context.Context, clusterName, action string, maxWait time.Duration) error
+}
+
+// PlanAPI lists the plans clusters can be created from
+type PlanAPI interface {
+	GetPlans(ctx context.Context) ([]Plan, error)
+}
+
+// SinkAPI manages the log and metric sinks of clusters
+type SinkAPI interface {
+	GetSink(ctx context.Context, clusterName, sinkName string) (*Sink, bool, error)
+	CreateSink(ctx context.Context, clusterName string, sink Sink) error
+	UpdateSink(ctx context.Context, clusterName, sinkName string, sink Sink) error
+	DeleteSink(ctx context.Context, clusterName, sinkName string) error
+}
+
+// ProfileAPI manages the network, compute and kubernetes profiles that clusters can be created with
+type ProfileAPI interface {
+	GetNetworkProfile(ctx context.Context, profileName string) (*NetworkProfile, bool, error)
+	CreateNetworkProfile(ctx context.Context, profile NetworkProfile) error
+	DeleteNetworkProfile(ctx context.Context, profileName string) error
+	GetComputeProfile(ctx context.Context, profileName string) (*ComputeProfile, bool, error)
+	CreateComputeProfile(ctx context.Context, profile ComputeProfile) error
+	DeleteComputeProfile(ctx context.Context, profileName string) error
+	GetKubernetesProfile(ctx context.Context, profileName string) (*KubernetesProfile, bool, error)
+	CreateKubernetesProfile(ctx context.Context, profile KubernetesProfile) error
+	DeleteKubernetesProfile(ctx context.Context, profileName string) error
+}
+
+// API is the whole of the PKS API, as implemented by Client
+type API interface {
+	ClusterAPI
+	PlanAPI
+	SinkAPI
+	ProfileAPI
+}
+
+var _ API = (*Client)(nil)
+
+func (client *Client) GetCluster(ctx context.Context, clusterName string) (*ClusterResponse, bool, error) {
+	reqUrl := client.apiUrl + "/v1/clusters/" + clusterName
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		// this doesn't catch 4xx/5xx !
+		return nil, false, fmt.Errorf("error reading cluster from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, false, nil
+	} else if resp.StatusCode > 299 {
+		return nil, false, fmt.Errorf("cluster read failed: %w", newAPIError(resp))
+	}
+
+	var cr ClusterResponse
+	err = json.NewDecoder(resp.Body).Decode(&cr)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing cluster response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &cr, true, nil
+}
+
+func (client *Client) GetClusterCredentials(ctx context.Context, clusterName string) (*KubeConfig, error) {
+	reqUrl := client.apiUrl + "/v1/clusters/" + clusterName + "/binds"
+	resp, err := client.doRequest(ctx, "POST", reqUrl, struct{}{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster credentials from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("cluster credentials request failed: %w", newAPIError(resp))
+	}
+
+	var kc KubeConfig
+	err = json.NewDecoder(resp.Body).Decode(&kc)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cluster credentials response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &kc, nil
+}
+
+func (client *Client) CreateCluster(ctx context.Context, clusterReq ClusterRequest) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/clusters", clusterReq)
+	if err != nil {
+		return fmt.Errorf("POST to API to create cluster failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("cluster creation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) UpdateCluster(ctx context.Context, clusterName string, updateClusterReq UpdateClusterParameters) error {
+	resp, err := client.doRequest(ctx, "PATCH", client.apiUrl+"/v1/clusters/"+clusterName, updateClusterReq)
+	if err != nil {
+		return fmt.Errorf("PATCH to API to update cluster failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("cluster update failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) UpgradeCluster(ctx context.Context, clusterName string) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/clusters/"+clusterName+"/upgrade", nil)
+	if err != nil {
+		return fmt.Errorf("POST to API to upgrade cluster failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("cluster upgrade failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) RotateClusterCertificates(ctx context.Context, clusterName string) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/clusters/"+clusterName+"/rotate-certificates", nil)
+	if err != nil {
+		return fmt.Errorf("POST to API to rotate cluster certificates failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("cluster certificate rotation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) DeleteCluster(ctx context.Context, clusterName string) error {
+	reqUrl := client.apiUrl + "/v1/clusters/" + clusterName
+	resp, err := client.doRequest(ctx, "DELETE", reqUrl, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting cluster from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		// cluster was already deleted
+		return nil
+	} else if resp.StatusCode > 299 {
+		return fmt.Errorf("cluster delete failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+func (client *Client) GetPlans(ctx context.Context) ([]Plan, error) {
+	reqUrl := client.apiUrl + "/v1/plans"
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plans from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("plans read failed: %w", newAPIError(resp))
+	}
+
+	var plans []Plan
+	err = json.NewDecoder(resp.Body).Decode(&plans)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing plans response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return plans, nil
+}
+
+func (client *Client) GetSink(ctx context.Context, clusterName, sinkName string) (*Sink, bool, error) {
+	reqUrl := client.apiUrl + "/v1/clusters/" + clusterName + "/sinks/" + sinkName
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading sink from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, false, nil
+	} else if resp.StatusCode > 299 {
+		return nil, false, fmt.Errorf("sink read failed: %w", newAPIError(resp))
+	}
+
+	var sink Sink
+	err = json.NewDecoder(resp.Body).Decode(&sink)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing sink response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &sink, true, nil
+}
+
+func (client *Client) CreateSink(ctx context.Context, clusterName string, sink Sink) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/clusters/"+clusterName+"/sinks", sink)
+	if err != nil {
+		return fmt.Errorf("POST to API to create sink failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("sink creation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) UpdateSink(ctx context.Context, clusterName, sinkName string, sink Sink) error {
+	resp, err := client.doRequest(ctx, "PUT", client.apiUrl+"/v1/clusters/"+clusterName+"/sinks/"+sinkName, sink)
+	if err != nil {
+		return fmt.Errorf("PUT to API to update sink failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("sink update failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) DeleteSink(ctx context.Context, clusterName, sinkName string) error {
+	reqUrl := client.apiUrl + "/v1/clusters/" + clusterName + "/sinks/" + sinkName
+	resp, err := client.doRequest(ctx, "DELETE", reqUrl, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting sink from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		// sink (or its cluster) was already deleted
+		return nil
+	} else if resp.StatusCode > 299 {
+		return fmt.Errorf("sink delete failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+func (client *Client) GetNetworkProfile(ctx context.Context, profileName string) (*NetworkProfile, bool, error) {
+	reqUrl := client.apiUrl + "/v1/network-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading network profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, false, nil
+	} else if resp.StatusCode > 299 {
+		return nil, false, fmt.Errorf("network profile read failed: %w", newAPIError(resp))
+	}
+
+	var np NetworkProfile
+	err = json.NewDecoder(resp.Body).Decode(&np)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing network profile response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &np, true, nil
+}
+
+func (client *Client) CreateNetworkProfile(ctx context.Context, profile NetworkProfile) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/network-profiles", profile)
+	if err != nil {
+		return fmt.Errorf("POST to API to create network profile failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("network profile creation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) DeleteNetworkProfile(ctx context.Context, profileName string) error {
+	reqUrl := client.apiUrl + "/v1/network-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "DELETE", reqUrl, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting network profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		// profile was already deleted
+		return nil
+	} else if resp.StatusCode > 299 {
+		return fmt.Errorf("network profile delete failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+func (client *Client) GetComputeProfile(ctx context.Context, profileName string) (*ComputeProfile, bool, error) {
+	reqUrl := client.apiUrl + "/v1/compute-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading compute profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, false, nil
+	} else if resp.StatusCode > 299 {
+		return nil, false, fmt.Errorf("compute profile read failed: %w", newAPIError(resp))
+	}
+
+	var cp ComputeProfile
+	err = json.NewDecoder(resp.Body).Decode(&cp)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing compute profile response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &cp, true, nil
+}
+
+func (client *Client) CreateComputeProfile(ctx context.Context, profile ComputeProfile) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/compute-profiles", profile)
+	if err != nil {
+		return fmt.Errorf("POST to API to create compute profile failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("compute profile creation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) DeleteComputeProfile(ctx context.Context, profileName string) error {
+	reqUrl := client.apiUrl + "/v1/compute-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "DELETE", reqUrl, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting compute profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		// profile was already deleted
+		return nil
+	} else if resp.StatusCode > 299 {
+		return fmt.Errorf("compute profile delete failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
+
+func (client *Client) GetKubernetesProfile(ctx context.Context, profileName string) (*KubernetesProfile, bool, error) {
+	reqUrl := client.apiUrl + "/v1/kubernetes-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "GET", reqUrl, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading kubernetes profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, false, nil
+	} else if resp.StatusCode > 299 {
+		return nil, false, fmt.Errorf("kubernetes profile read failed: %w", newAPIError(resp))
+	}
+
+	var kp KubernetesProfile
+	err = json.NewDecoder(resp.Body).Decode(&kp)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing kubernetes profile response from PKS API %q: %q", reqUrl, err.Error())
+	}
+	return &kp, true, nil
+}
+
+func (client *Client) CreateKubernetesProfile(ctx context.Context, profile KubernetesProfile) error {
+	resp, err := client.doRequest(ctx, "POST", client.apiUrl+"/v1/kubernetes-profiles", profile)
+	if err != nil {
+		return fmt.Errorf("POST to API to create kubernetes profile failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("kubernetes profile creation failed: %w", newAPIError(resp))
+	}
+	return nil
+}
+
+func (client *Client) DeleteKubernetesProfile(ctx context.Context, profileName string) error {
+	reqUrl := client.apiUrl + "/v1/kubernetes-profiles/" + profileName
+	resp, err := client.doRequest(ctx, "DELETE", reqUrl, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting kubernetes profile from PKS API %q: %w", reqUrl, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		// profile was already deleted
+		return nil
+	} else if resp.StatusCode > 299 {
+		return fmt.Errorf("kubernetes profile delete failed: %w", newAPIError(resp))
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package pksapi
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/go-cleanhttp"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryClient(serverUrl string) *Client {
+	return &Client{
+		apiUrl:       serverUrl,
+		token:        "test-token",
+		httpClient:   cleanhttp.DefaultClient(),
+		maxRetries:   3,
+		retryMinWait: time.Millisecond,
+		retryMaxWait: 10 * time.Millisecond,
+		clock:        SystemClock,
+	}
+}
+
+// statusSequenceServer responds with each of statuses in turn, then with 200 for any further requests
+func statusSequenceServer(statuses []int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(calls, 1)
+		if int(call) <= len(statuses) {
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+}
+
+func TestSendRequestWithRetries(t *testing.T) {
+	testCases := map[string]struct {
+		method         string
+		statuses       []int
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		"idempotent request retried after gateway errors": {
+			method:         "GET",
+			statuses:       []int{502, 503, 504},
+			expectedStatus: 200,
+			expectedCalls:  4,
+		},
+		"idempotent request gives up after max retries": {
+			method:         "DELETE",
+			statuses:       []int{500, 500, 500, 500, 500},
+			expectedStatus: 500,
+			expectedCalls:  4,
+		},
+		"mutating request not retried after server error": {
+			method:         "POST",
+			statuses:       []int{500},
+			expectedStatus: 500,
+			expectedCalls:  1,
+		},
+		"mutating request retried when turned away": {
+			method:         "PATCH",
+			statuses:       []int{429, 503},
+			expectedStatus: 200,
+			expectedCalls:  3,
+		},
+		"client errors not retried": {
+			method:         "GET",
+			statuses:       []int{422},
+			expectedStatus: 422,
+			expectedCalls:  1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var calls int32
+			server := statusSequenceServer(tc.statuses, &calls)
+			defer server.Close()
+
+			client := testRetryClient(server.URL)
+			resp, err := client.sendRequestWithRetries(context.Background(), tc.method, server.URL+"/v1/clusters", nil, client.token)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected final status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d requests, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestSendRequestWithRetries_networkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverUrl := server.URL
+	// nothing will be listening, so every request fails to connect
+	server.Close()
+
+	client := testRetryClient(serverUrl)
+	_, err := client.sendRequestWithRetries(context.Background(), "GET", serverUrl+"/v1/clusters", nil, client.token)
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	client := testRetryClient("")
+	client.retryMaxWait = time.Minute
+
+	testCases := map[string]struct {
+		status     int
+		retryAfter string
+		expected   time.Duration
+	}{
+		"seconds":            {status: 503, retryAfter: "5", expected: 5 * time.Second},
+		"capped to max wait": {status: 429, retryAfter: "3600", expected: time.Minute},
+		"date in the past":   {status: 429, retryAfter: "Wed, 21 Oct 2015 07:28:00 GMT", expected: 0},
+		"missing":            {status: 503, retryAfter: "", expected: 0},
+		"ignored for 500":    {status: 500, retryAfter: "5", expected: 0},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tc.status, Header: http.Header{}}
+			if tc.retryAfter != "" {
+				resp.Header.Set("Retry-After", tc.retryAfter)
+			}
+			if wait := client.retryAfter(resp); wait != tc.expected {
+				t.Errorf("expected wait of %s, got %s", tc.expected, wait)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	client := testRetryClient("")
+	client.retryMinWait = time.Second
+	client.retryMaxWait = 30 * time.Second
+
+	for attempt := int64(0); attempt < 70; attempt++ {
+		wait := client.retryBackoff(attempt)
+		expectedMax := client.retryMinWait << uint(attempt)
+		if expectedMax <= 0 || expectedMax > client.retryMaxWait {
+			expectedMax = client.retryMaxWait
+		}
+		if wait < expectedMax/2 || wait > expectedMax {
+			t.Errorf("attempt %d: wait %s outside of expected range %s-%s", attempt, wait, expectedMax/2, expectedMax)
+		}
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	testCases := map[string]struct {
+		status          int
+		body            string
+		expectedMessage string
+		isConflict      bool
+		isNotFound      bool
+		isUnauthorized  bool
+	}{
+		"PKS error": {
+			status:          409,
+			body:            `{"error": "conflict", "description": "Cluster name my-cluster is already taken"}`,
+			expectedMessage: "Cluster name my-cluster is already taken",
+			isConflict:      true,
+		},
+		"UAA error": {
+			status:          401,
+			body:            `{"error": "unauthorized", "error_description": "Bad credentials"}`,
+			expectedMessage: "Bad credentials",
+			isUnauthorized:  true,
+		},
+		"not JSON": {
+			status:          404,
+			body:            `404 page not found`,
+			expectedMessage: "",
+			isNotFound:      true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := testRetryClient(server.URL)
+			err := client.CreateCluster(context.Background(), ClusterRequest{Name: "my-cluster"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %#v", err)
+			}
+			if apiErr.StatusCode != tc.status || apiErr.Method != "POST" || apiErr.URL != server.URL+"/v1/clusters" {
+				t.Errorf("unexpected error details: %#v", apiErr)
+			}
+			if apiErr.Message != tc.expectedMessage {
+				t.Errorf("expected message %q, got %q", tc.expectedMessage, apiErr.Message)
+			}
+			if string(apiErr.Body) != tc.body {
+				t.Errorf("expected body %q, got %q", tc.body, apiErr.Body)
+			}
+			if IsConflict(err) != tc.isConflict || IsNotFound(err) != tc.isNotFound || IsUnauthorized(err) != tc.isUnauthorized {
+				t.Errorf("unexpected result from status helpers for %d", tc.status)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	testCases := map[string]struct {
+		opts        []Option
+		expectError bool
+	}{
+		"token": {
+			opts: []Option{WithBaseURL("https://pks.example.com:9021/"), WithToken("token")},
+		},
+		"client credentials": {
+			opts: []Option{WithBaseURL("https://pks.example.com:9021"), WithUAAURL("https://pks.example.com:8443"), WithClientCredentials("id", "secret")},
+		},
+		"user credentials without UAA": {
+			opts:        []Option{WithBaseURL("https://pks.example.com:9021"), WithUserCredentials("user", "password")},
+			expectError: true,
+		},
+		"no base URL": {
+			opts:        []Option{WithToken("token")},
+			expectError: true,
+		},
+		"no auth": {
+			opts:        []Option{WithBaseURL("https://pks.example.com:9021")},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, err := NewClient(tc.opts...)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.apiUrl != "https://pks.example.com:9021" {
+				t.Errorf("unexpected API URL %q", client.apiUrl)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package pksapitest
+
+import (
+	"context"
+	"github.com/benjvi/terraform-provider-pks/pksapi"
+	"testing"
+	"time"
+)
+
+func TestServer_clusterLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.ActionDuration = 200 * time.Millisecond
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ctx := context.Background()
+
+	err = client.CreateCluster(ctx, pksapi.ClusterRequest{
+		Name:       "my-cluster",
+		PlanName:   "small",
+		Parameters: pksapi.ClusterParameters{KubernetesMasterHost: "my-cluster.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cr, exists, err := client.GetCluster(ctx, "my-cluster")
+	if err != nil || !exists {
+		t.Fatalf("expected the cluster to exist, err: %v", err)
+	}
+	if cr.LastAction != "CREATE" || cr.LastActionState != "in progress" {
+		t.Fatalf("unexpected state %q %q straight after create", cr.LastAction, cr.LastActionState)
+	}
+	if cr.Parameters.KubernetesWorkerInstances != 3 {
+		t.Errorf("expected the plan's 3 workers, got %d", cr.Parameters.KubernetesWorkerInstances)
+	}
+
+	err = client.CreateCluster(ctx, pksapi.ClusterRequest{
+		Name:       "my-cluster",
+		PlanName:   "small",
+		Parameters: pksapi.ClusterParameters{KubernetesMasterHost: "my-cluster.example.com"},
+	})
+	if !pksapi.IsConflict(err) {
+		t.Errorf("expected a conflict creating a duplicate cluster, got %v", err)
+	}
+
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "CREATE", 10*time.Second); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.UpdateCluster(ctx, "my-cluster", pksapi.UpdateClusterParameters{KubernetesWorkerInstances: 5}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.UpdateCluster(ctx, "my-cluster", pksapi.UpdateClusterParameters{KubernetesWorkerInstances: 6}); !pksapi.IsConflict(err) {
+		t.Errorf("expected a conflict updating during another update, got %v", err)
+	}
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "UPDATE", 10*time.Second); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	cr, _, err = client.GetCluster(ctx, "my-cluster")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if cr.Parameters.KubernetesWorkerInstances != 5 {
+		t.Errorf("expected 5 workers after update, got %d", cr.Parameters.KubernetesWorkerInstances)
+	}
+
+	if err := client.DeleteCluster(ctx, "my-cluster"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "DELETE", 10*time.Second); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, exists, _ := client.GetCluster(ctx, "my-cluster"); exists {
+		t.Error("expected the cluster to be gone after delete")
+	}
+}
+
+func TestServer_failedAction(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ctx := context.Background()
+
+	err = client.CreateCluster(ctx, pksapi.ClusterRequest{
+		Name:       "my-cluster",
+		PlanName:   "small",
+		Parameters: pksapi.ClusterParameters{KubernetesMasterHost: "my-cluster.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := server.SetClusterState("my-cluster", "CREATE", "failed", "no capacity"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "CREATE", 10*time.Second); err == nil {
+		t.Fatal("expected waiting on a failed action to return an error")
+	}
+}
+
+func TestServer_upgrade(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.ActionDuration = 200 * time.Millisecond
+	server.UpgradeVersion = "1.7.0"
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ctx := context.Background()
+
+	err = client.CreateCluster(ctx, pksapi.ClusterRequest{
+		Name:       "my-cluster",
+		PlanName:   "small",
+		Parameters: pksapi.ClusterParameters{KubernetesMasterHost: "my-cluster.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "CREATE", 10*time.Second); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.UpgradeCluster(ctx, "my-cluster"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := client.WaitForClusterAction(ctx, "my-cluster", "UPGRADE", 10*time.Second); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cr, _, err := client.GetCluster(ctx, "my-cluster")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if cr.PksVersion != "1.7.0" {
+		t.Errorf("expected the cluster to be upgraded to 1.7.0, got %q", cr.PksVersion)
+	}
+}
+
+func TestServer_auth(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	testCases := map[string]struct {
+		opts      []pksapi.Option
+		expectErr bool
+	}{
+		"client credentials": {},
+		"user credentials": {
+			opts: []pksapi.Option{pksapi.WithClientCredentials("", ""), pksapi.WithUserCredentials(Username, Password)},
+		},
+		"bad credentials": {
+			opts:      []pksapi.Option{pksapi.WithClientCredentials(ClientID, "wrong")},
+			expectErr: true,
+		},
+		"unknown token": {
+			opts:      []pksapi.Option{pksapi.WithClientCredentials("", ""), pksapi.WithToken("not-issued")},
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, err := server.Client(tc.opts...)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			_, err = client.GetPlans(context.Background())
+			if tc.expectErr && err == nil {
+				t.Fatal("expected an error")
+			} else if !tc.expectErr && err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package pksapi
+
+import "encoding/json"
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	Jti         string `json:"jti"`
+}
+
+type ClusterRequest struct {
+	Name       string            `json:"name"`
+	PlanName   string            `json:"plan_name"`
+	Parameters ClusterParameters `json:"parameters"`
+}
+
+type ClusterParameters struct {
+	KubernetesMasterHost      string `json:"kubernetes_master_host"`
+	KubernetesMasterPort      int64  `json:"kubernetes_master_port,omitempty"`
+	KubernetesWorkerInstances int64  `json:"kubernetes_worker_instances,omitempty"`
+	ComputeProfileName        string `json:"compute_profile_name,omitempty"`
+	KubernetesProfileName     string `json:"kubernetes_profile_name,omitempty"`
+	NsxtNetworkProfile        string `json:"nsxt_network_profile,omitempty"`
+	Tags                      []Tag  `json:"tags,omitempty"`
+	// NodePools override the node pools of the cluster's compute profile, matched by name
+	NodePools []NodePool `json:"node_pools,omitempty"`
+}
+
+// Tag is added to the metadata of the cluster's VMs on the IaaS
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type ClusterResponse struct {
+	Name                  string            `json:"name"`
+	PlanName              string            `json:"plan_name"`
+	LastAction            string            `json:"last_action"`
+	LastActionState       string            `json:"last_action_state"`
+	LastActionDescription string            `json:"last_action_description"`
+	Uuid                  string            `json:"uuid"`
+	K8sVersion            string            `json:"k8s_version"`
+	PksVersion            string            `json:"pks_version"`
+	KubernetesMasterIps   []string          `json:"kubernetes_master_ips"`
+	Parameters            ClusterParameters `json:"parameters"`
+}
+
+// KubeConfig is the kubeconfig document returned when binding to a cluster, as used by kubectl
+type KubeConfig struct {
+	ApiVersion     string                   `json:"apiVersion"`
+	Kind           string                   `json:"kind"`
+	Clusters       []KubeConfigNamedCluster `json:"clusters"`
+	Users          []KubeConfigNamedUser    `json:"users"`
+	Contexts       []KubeConfigNamedContext `json:"contexts"`
+	CurrentContext string                   `json:"current-context"`
+}
+
+type KubeConfigNamedCluster struct {
+	Name    string            `json:"name"`
+	Cluster KubeConfigCluster `json:"cluster"`
+}
+
+type KubeConfigCluster struct {
+	Server                   string `json:"server"`
+	CertificateAuthorityData string `json:"certificate-authority-data,omitempty"`
+}
+
+type KubeConfigNamedUser struct {
+	Name string         `json:"name"`
+	User KubeConfigUser `json:"user"`
+}
+
+type KubeConfigUser struct {
+	Token                 string `json:"token,omitempty"`
+	ClientCertificateData string `json:"client-certificate-data,omitempty"`
+	ClientKeyData         string `json:"client-key-data,omitempty"`
+}
+
+type KubeConfigNamedContext struct {
+	Name    string            `json:"name"`
+	Context KubeConfigContext `json:"context"`
+}
+
+type KubeConfigContext struct {
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type Plan struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	WorkerInstances    int64  `json:"worker_instances"`
+	MaxWorkerInstances int64  `json:"max_worker_instances"`
+	MasterInstances    int64  `json:"master_instances"`
+}
+
+type UpdateClusterParameters struct {
+	KubernetesWorkerInstances int64  `json:"kubernetes_worker_instances,omitempty"`
+	NsxtNetworkProfile        string `json:"nsxt_network_profile,omitempty"`
+	// NodePools only need to include the pools being changed
+	NodePools []NodePool `json:"node_pools,omitempty"`
+	// Tags replaces all of the cluster's tags when set, so an empty list removes them
+	Tags *[]Tag `json:"tags,omitempty"`
+}
+
+type Sink struct {
+	Name               string `json:"name"`
+	Type               string `json:"type"`
+	Host               string `json:"host"`
+	Port               int64  `json:"port"`
+	EnableTls          bool   `json:"enable_tls"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+type ComputeProfile struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	Parameters  ComputeProfileParameters `json:"parameters"`
+}
+
+type ComputeProfileParameters struct {
+	Azs                  []ComputeProfileAz   `json:"azs,omitempty"`
+	ClusterCustomization ClusterCustomization `json:"cluster_customization"`
+}
+
+type ComputeProfileAz struct {
+	Name            string          `json:"name"`
+	Cpi             string          `json:"cpi,omitempty"`
+	CloudProperties json.RawMessage `json:"cloud_properties,omitempty"`
+}
+
+type ClusterCustomization struct {
+	ControlPlane *ControlPlane `json:"control_plane,omitempty"`
+	NodePools    []NodePool    `json:"node_pools"`
+}
+
+type ControlPlane struct {
+	Instances    int64    `json:"instances"`
+	InstanceType string   `json:"instance_type,omitempty"`
+	AzNames      []string `json:"az_names,omitempty"`
+}
+
+type NodePool struct {
+	Name         string            `json:"name"`
+	Instances    int64             `json:"instances"`
+	InstanceType string            `json:"instance_type,omitempty"`
+	AzNames      []string          `json:"az_names,omitempty"`
+	NodeLabels   map[string]string `json:"node_labels,omitempty"`
+	NodeTaints   []string          `json:"node_taints,omitempty"`
+}
+
+type KubernetesProfile struct {
+	Name           string                           `json:"name"`
+	Description    string                           `json:"description,omitempty"`
+	Customizations []KubernetesProfileCustomization `json:"customizations"`
+}
+
+type KubernetesProfileCustomization struct {
+	Component     string          `json:"component"`
+	Arguments     json.RawMessage `json:"arguments,omitempty"`
+	FileArguments json.RawMessage `json:"file-arguments,omitempty"`
+}
+
+type NetworkProfile struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
@@ -0,0 +1,113 @@
+package pksapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// it may take a few moments for an action to be registered in PKS, so a missing cluster or a different
+// action is tolerated for this many polls
+const maxPollingRetries = 3
+
+// Clock is the source of time for waits, so they can be tested without sleeping
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d to pass, returning the context's error if it is done first
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the real clock, used unless WithClock is given
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ClusterPoller gets the current state of a cluster, returning false if it doesn't exist
+type ClusterPoller func(ctx context.Context, clusterName string) (*ClusterResponse, bool, error)
+
+// ClusterActionWaiter polls a cluster until its last action finishes
+type ClusterActionWaiter struct {
+	Poll         ClusterPoller
+	Clock        Clock
+	PollInterval time.Duration
+}
+
+func (client *Client) WaitForClusterAction(ctx context.Context, clusterName, action string, maxWait time.Duration) error {
+	waiter := ClusterActionWaiter{
+		Poll:         client.GetCluster,
+		Clock:        client.clock,
+		PollInterval: client.pollInterval,
+	}
+	return waiter.Wait(ctx, clusterName, action, maxWait)
+}
+
+// Wait returns nil once action has succeeded on the cluster, or for a DELETE once the cluster is gone.
+// It returns an ActionFailedError if the action fails, or another error if it hasn't finished within maxWait.
+func (w ClusterActionWaiter) Wait(ctx context.Context, clusterName, action string, maxWait time.Duration) error {
+	deadline := w.Clock.Now().Add(maxWait)
+	pollingRetries := 0
+
+	// Keep trying until we're timed out or got a result or got an error
+	for {
+		if err := w.Clock.Sleep(ctx, w.PollInterval); err != nil {
+			return fmt.Errorf("Stopped waiting for action %q to succeed on cluster %q: %w", action, clusterName, err)
+		}
+		if !w.Clock.Now().Before(deadline) {
+			return fmt.Errorf("Timed out waiting for action %q to succeed on cluster %q", action, clusterName)
+		}
+
+		cr, exists, err := w.Poll(ctx, clusterName)
+		if err != nil {
+			return err
+		}
+
+		// checking if cluster exists
+		if strings.EqualFold("DELETE", action) && !exists {
+			// delete action completed ok
+			return nil
+		} else if !exists {
+			if pollingRetries < maxPollingRetries {
+				pollingRetries = pollingRetries + 1
+				continue
+			}
+			return fmt.Errorf("Cluster %q not found while waiting for action %q", clusterName, action)
+		}
+
+		// checking the action is what we expected
+		if !strings.EqualFold(cr.LastAction, action) {
+			if pollingRetries < maxPollingRetries {
+				pollingRetries = pollingRetries + 1
+				continue
+			}
+			return fmt.Errorf("Found an unexpected action on our cluster: %q, status: %q (%q)", cr.LastAction,
+				cr.LastActionState, cr.LastActionDescription)
+		}
+
+		// check the status of our action
+		if strings.EqualFold(cr.LastActionState, "in progress") {
+			continue
+		} else if strings.EqualFold(cr.LastActionState, "failed") {
+			return &ActionFailedError{ClusterName: clusterName, Action: action, Description: cr.LastActionDescription}
+		} else if strings.EqualFold(cr.LastActionState, "succeeded") {
+			return nil
+		} else {
+			return fmt.Errorf("Unexpected cluster status: %q", cr.LastActionState)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package pksapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock moves time forward when slept on, so waits return straight away
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// pollResult is the response to one poll of the cluster
+type pollResult struct {
+	cr     *ClusterResponse
+	exists bool
+	err    error
+}
+
+func clusterState(action, state string) pollResult {
+	return pollResult{
+		cr: &ClusterResponse{
+			Name:                  "my-cluster",
+			LastAction:            action,
+			LastActionState:       state,
+			LastActionDescription: "Instance provisioning " + state,
+		},
+		exists: true,
+	}
+}
+
+var clusterMissing = pollResult{}
+
+// scriptedPoller returns each of results in turn, repeating the last one once they run out
+func scriptedPoller(results []pollResult, polls *int) ClusterPoller {
+	return func(ctx context.Context, clusterName string) (*ClusterResponse, bool, error) {
+		r := results[len(results)-1]
+		if *polls < len(results) {
+			r = results[*polls]
+		}
+		*polls++
+		return r.cr, r.exists, r.err
+	}
+}
+
+func TestClusterActionWaiter(t *testing.T) {
+	pollErr := errors.New("connection refused")
+
+	testCases := map[string]struct {
+		action        string
+		results       []pollResult
+		cancelled     bool
+		expectedErr   string
+		expectFailed  bool
+		expectedPolls int
+	}{
+		"succeeds after being in progress": {
+			action:        "CREATE",
+			results:       []pollResult{clusterState("CREATE", "in progress"), clusterState("CREATE", "in progress"), clusterState("CREATE", "succeeded")},
+			expectedPolls: 3,
+		},
+		"action state compared case insensitively": {
+			action:        "update",
+			results:       []pollResult{clusterState("UPDATE", "Succeeded")},
+			expectedPolls: 1,
+		},
+		"fails with the action description": {
+			action:        "CREATE",
+			results:       []pollResult{clusterState("CREATE", "in progress"), clusterState("CREATE", "failed")},
+			expectedErr:   "Instance provisioning failed",
+			expectFailed:  true,
+			expectedPolls: 2,
+		},
+		"unexpected state": {
+			action:        "CREATE",
+			results:       []pollResult{clusterState("CREATE", "paused")},
+			expectedErr:   `Unexpected cluster status: "paused"`,
+			expectedPolls: 1,
+		},
+		"missing cluster tolerated while the action registers": {
+			action:        "CREATE",
+			results:       []pollResult{clusterMissing, clusterMissing, clusterMissing, clusterState("CREATE", "succeeded")},
+			expectedPolls: 4,
+		},
+		"missing cluster after the grace period": {
+			action:        "CREATE",
+			results:       []pollResult{clusterMissing},
+			expectedErr:   `Cluster "my-cluster" not found while waiting for action "CREATE"`,
+			expectedPolls: 4,
+		},
+		"previous action tolerated while the action registers": {
+			action:        "UPDATE",
+			results:       []pollResult{clusterState("CREATE", "succeeded"), clusterState("UPDATE", "in progress"), clusterState("UPDATE", "succeeded")},
+			expectedPolls: 3,
+		},
+		"unexpected action after the grace period": {
+			action:        "UPDATE",
+			results:       []pollResult{clusterState("UPGRADE", "in progress")},
+			expectedErr:   `Found an unexpected action on our cluster: "UPGRADE"`,
+			expectedPolls: 4,
+		},
+		"grace period shared between missing cluster and other action": {
+			action:        "UPDATE",
+			results:       []pollResult{clusterMissing, clusterMissing, clusterState("CREATE", "succeeded"), clusterState("CREATE", "succeeded")},
+			expectedErr:   "Found an unexpected action on our cluster",
+			expectedPolls: 4,
+		},
+		"delete succeeds once the cluster is gone": {
+			action:        "DELETE",
+			results:       []pollResult{clusterState("DELETE", "in progress"), clusterMissing},
+			expectedPolls: 2,
+		},
+		"delete of a missing cluster succeeds straight away": {
+			action:        "DELETE",
+			results:       []pollResult{clusterMissing},
+			expectedPolls: 1,
+		},
+		"delete fails": {
+			action:        "DELETE",
+			results:       []pollResult{clusterState("DELETE", "failed")},
+			expectedErr:   "Instance provisioning failed",
+			expectFailed:  true,
+			expectedPolls: 1,
+		},
+		"times out while in progress": {
+			action:  "CREATE",
+			results: []pollResult{clusterState("CREATE", "in progress")},
+			// polls at 10s to 50s, then times out at 60s
+			expectedErr:   `Timed out waiting for action "CREATE" to succeed on cluster "my-cluster"`,
+			expectedPolls: 5,
+		},
+		"poll error returned": {
+			action:        "CREATE",
+			results:       []pollResult{clusterState("CREATE", "in progress"), {err: pollErr}},
+			expectedErr:   "connection refused",
+			expectedPolls: 2,
+		},
+		"stops when cancelled": {
+			action:        "CREATE",
+			results:       []pollResult{clusterState("CREATE", "in progress")},
+			cancelled:     true,
+			expectedErr:   "Stopped waiting for action",
+			expectedPolls: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+
+			polls := 0
+			clock := &fakeClock{now: time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)}
+			waiter := ClusterActionWaiter{
+				Poll:         scriptedPoller(tc.results, &polls),
+				Clock:        clock,
+				PollInterval: 10 * time.Second,
+			}
+
+			err := waiter.Wait(ctx, "my-cluster", tc.action, time.Minute)
+			if tc.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if tc.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedErr)) {
+				t.Fatalf("expected an error containing %q, got %v", tc.expectedErr, err)
+			}
+			if polls != tc.expectedPolls {
+				t.Errorf("expected %d polls, got %d", tc.expectedPolls, polls)
+			}
+			if IsActionFailed(err) != tc.expectFailed {
+				t.Errorf("expected IsActionFailed to be %t for %v", tc.expectFailed, err)
+			}
+			if tc.cancelled && !errors.Is(err, context.Canceled) {
+				t.Errorf("expected a cancellation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWaitForClusterAction_cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "my-cluster", "last_action": "CREATE", "last_action_state": "in progress"}`))
+	}))
+	defer server.Close()
+
+	client := testRetryClient(server.URL)
+	client.pollInterval = time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.WaitForClusterAction(ctx, "my-cluster", "CREATE", 20*time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to stop waiting after cancellation", elapsed)
+	}
+}